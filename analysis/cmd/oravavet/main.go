@@ -0,0 +1,19 @@
+// Command oravavet runs the namedquery analyzer.
+//
+// It is installed with:
+//
+//	go install github.com/anton7r/orava/analysis/cmd/oravavet@latest
+//
+// It can be used on its own or through go vet:
+//
+//	go vet -vettool=$(which oravavet) ./...
+package main
+
+import (
+	"github.com/anton7r/orava/analysis/namedquery"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(namedquery.Analyzer)
+}
@@ -0,0 +1,338 @@
+// Package namedquery defines an Analyzer that checks the named queries
+// given to orava as constant strings against the Go types they are used with.
+package namedquery
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anton7r/orava/dbquery"
+	"github.com/anton7r/orava/internal/typemap"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const doc = `check orava named queries against their argument and destination types
+
+The namedquery analyzer finds calls to SelectNamed, GetNamed, ExecNamed,
+QueryNamed and PrepareNamed with a constant query string. It reports named
+params and {{identifiers}} that have no matching field in the argument struct,
+and columns of a SELECT list that have no matching field in the destination struct.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "namedquery",
+	Doc:      doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var (
+	delimFlag       string
+	tagFlag         string
+	placeholderFlag string
+	separatorFlag   string
+	mapperFlag      string
+)
+
+func init() {
+	Analyzer.Flags.StringVar(&delimFlag, "delim", ":", "delimiter of the named params")
+	Analyzer.Flags.StringVar(&tagFlag, "tag", typemap.DefaultMapper.TagKey, "struct tag key used for mapping columns")
+	Analyzer.Flags.StringVar(&placeholderFlag, "placeholder", "dollar", "placeholders the named params are compiled to: dollar ($1) or question (?)")
+	Analyzer.Flags.StringVar(&separatorFlag, "separator", typemap.DefaultMapper.ColumnSeparator, "separator of the columns of nested structs")
+	Analyzer.Flags.StringVar(&mapperFlag, "mapper", "snake", "mapping of the field names to columns: snake (FooBar to foo_bar), lower (foobar) or none (FooBar)")
+}
+
+var placeholders = map[string]dbquery.DriverDelim{
+	"dollar":   dbquery.SequentialDollarDelim,
+	"question": dbquery.QuestionDelim,
+}
+
+var fieldMappers = map[string]dbquery.NameMapperFunc{
+	"snake": dbquery.SnakeCaseMapper,
+	"lower": strings.ToLower,
+	"none":  func(name string) string { return name },
+}
+
+var oravaPackages = map[string]bool{
+	"github.com/anton7r/orava/dbquery":  true,
+	"github.com/anton7r/orava/pgxquery": true,
+	"github.com/anton7r/orava/sqlquery": true,
+}
+
+var namedMethods = map[string]bool{
+	"SelectNamed":  true,
+	"GetNamed":     true,
+	"ExecNamed":    true,
+	"QueryNamed":   true,
+	"PrepareNamed": true,
+}
+
+type checker struct {
+	pass   *analysis.Pass
+	api    *dbquery.API
+	mapper typemap.Mapper
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	delim, width := utf8.DecodeRuneInString(delimFlag)
+	if width == 0 || width != len(delimFlag) {
+		return nil, analysisError("delimiter must be a single character, got '" + delimFlag + "'")
+	}
+
+	placeholder, ok := placeholders[placeholderFlag]
+	if !ok {
+		return nil, analysisError("unknown placeholder '" + placeholderFlag + "', expected dollar or question")
+	}
+	fieldMapper, ok := fieldMappers[mapperFlag]
+	if !ok {
+		return nil, analysisError("unknown mapper '" + mapperFlag + "', expected snake, lower or none")
+	}
+
+	api, err := dbquery.NewAPI(dbquery.WithLexer(delim, placeholder), dbquery.WithStructTagKey(tagFlag))
+	if err != nil {
+		return nil, err
+	}
+
+	mapper := typemap.Mapper{TagKey: tagFlag, ColumnSeparator: separatorFlag, FieldMapperFn: fieldMapper}
+	c := &checker{pass: pass, api: api, mapper: mapper}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		c.checkCall(n.(*ast.CallExpr))
+	})
+	return nil, nil
+}
+
+type analysisError string
+
+func (e analysisError) Error() string {
+	return "namedquery: " + string(e)
+}
+
+func (c *checker) checkCall(call *ast.CallExpr) {
+	fn, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || !oravaPackages[fn.Pkg().Path()] || !namedMethods[fn.Name()] {
+		return
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Recv() == nil {
+		return
+	}
+
+	var queryExpr, dstExpr ast.Expr
+	var argExprs []ast.Expr
+	params := sig.Params()
+	for i := 0; i < params.Len() && i < len(call.Args); i++ {
+		switch params.At(i).Name() {
+		case "query":
+			queryExpr = call.Args[i]
+		case "dst":
+			dstExpr = call.Args[i]
+		case "arg":
+			argExprs = append(argExprs, call.Args[i])
+		case "args", "assertableStruct":
+			if sig.Variadic() && i == params.Len()-1 && !call.Ellipsis.IsValid() {
+				argExprs = append(argExprs, call.Args[i:]...)
+			}
+		}
+	}
+
+	// Only constant queries can be checked, PreparedQuery methods
+	// don't take the query so they have been checked at PrepareNamed.
+	if queryExpr == nil {
+		return
+	}
+	tv, ok := c.pass.TypesInfo.Types[queryExpr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return
+	}
+	query := constant.StringVal(tv.Value)
+
+	prep, err := c.api.PrepareNamed(query)
+	if err != nil {
+		c.pass.Reportf(queryExpr.Pos(), "invalid named query: %v", err)
+		return
+	}
+
+	names := append(prep.NamedParams(), prep.Idents()...)
+	for _, argExpr := range argExprs {
+		c.checkArg(argExpr, names)
+	}
+
+	if dstExpr != nil {
+		c.checkDst(dstExpr, query)
+	}
+}
+
+// checkArg reports the named params that have no field in the argument struct.
+func (c *checker) checkArg(argExpr ast.Expr, names []string) {
+	t := c.pass.TypesInfo.TypeOf(argExpr)
+	if t == nil {
+		return
+	}
+	st := typemap.StructOf(t)
+	if st == nil {
+		// Maps and interface values are only known at runtime.
+		return
+	}
+
+	columns := c.mapper.Columns(st)
+	for _, name := range names {
+		if field, found := columns[name]; !found || field.InSlice {
+			c.pass.Reportf(argExpr.Pos(), "field '%s' was not found from '%s' struct", name, typeName(t))
+		}
+	}
+}
+
+// checkDst reports the columns of the SELECT list that have no field in the destination struct.
+func (c *checker) checkDst(dstExpr ast.Expr, query string) {
+	t := c.pass.TypesInfo.TypeOf(dstExpr)
+	ptr, ok := t.Underlying().(*types.Pointer)
+	if !ok {
+		return
+	}
+	elem := ptr.Elem()
+	if slice, ok := elem.Underlying().(*types.Slice); ok {
+		elem = slice.Elem()
+	}
+	st := typemap.StructOf(elem)
+	if st == nil || isScanner(elem) {
+		return
+	}
+
+	selected, ok := selectColumns(query)
+	if !ok {
+		return
+	}
+
+	columns := c.mapper.Columns(st)
+	for _, column := range selected {
+		if _, found := columns[column]; !found {
+			c.pass.Reportf(dstExpr.Pos(), "column '%s' has no corresponding field in '%s' struct", column, typeName(elem))
+		}
+	}
+}
+
+func isScanner(t types.Type) bool {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), true, nil, "Scan")
+	_, isFunc := obj.(*types.Func)
+	return isFunc
+}
+
+func typeName(t types.Type) string {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return t.String()
+}
+
+// selectColumns returns the names of the columns in the SELECT list of the query.
+// It returns false when the names can't be known statically,
+// e.g. when the query is not a plain SELECT or it selects a star or an unnamed expression.
+func selectColumns(query string) ([]string, bool) {
+	query = strings.TrimSpace(query)
+	if len(query) < len("SELECT ") || !strings.EqualFold(query[:len("SELECT")], "SELECT") || !isSpace(query[len("SELECT")]) {
+		return nil, false
+	}
+	list := strings.TrimSpace(query[len("SELECT"):])
+	if len(list) > len("DISTINCT ") && strings.EqualFold(list[:len("DISTINCT")], "DISTINCT") && isSpace(list[len("DISTINCT")]) {
+		list = strings.TrimSpace(list[len("DISTINCT"):])
+	}
+
+	var items []string
+	depth := 0
+	inQuote := byte(0)
+	start := 0
+	for i := 0; i < len(list); i++ {
+		ch := list[i]
+		switch {
+		case inQuote != 0:
+			if ch == inQuote {
+				inQuote = 0
+			}
+		case ch == '\'' || ch == '"':
+			inQuote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case depth == 0 && ch == ',':
+			items = append(items, list[start:i])
+			start = i + 1
+		case depth == 0 && isKeywordAt(list, i, "FROM"):
+			items = append(items, list[start:i])
+			return columnNames(items)
+		}
+	}
+	items = append(items, list[start:])
+	return columnNames(items)
+}
+
+func columnNames(items []string) ([]string, bool) {
+	columns := make([]string, 0, len(items))
+	for _, item := range items {
+		fields := strings.Fields(item)
+		if len(fields) == 0 {
+			return nil, false
+		}
+		name := fields[len(fields)-1]
+		if len(fields) == 1 {
+			// Plain column reference, possibly qualified with the table name.
+			name = name[strings.LastIndex(name, ".")+1:]
+		} else if len(fields) > 2 && !strings.EqualFold(fields[len(fields)-2], "AS") {
+			// Longer expressions need an explicit alias to be recognised.
+			return nil, false
+		}
+		if name == "*" || strings.HasSuffix(name, "*") {
+			return nil, false
+		}
+		if strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) && len(name) > 1 {
+			name = name[1 : len(name)-1]
+		} else if !isIdent(name) {
+			// Expressions without an alias get names from the database.
+			return nil, false
+		} else {
+			name = strings.ToLower(name)
+		}
+		columns = append(columns, name)
+	}
+	return columns, true
+}
+
+func isKeywordAt(s string, i int, keyword string) bool {
+	if i > 0 && !isSpace(s[i-1]) && s[i-1] != ')' {
+		return false
+	}
+	end := i + len(keyword)
+	if end > len(s) || !strings.EqualFold(s[i:end], keyword) {
+		return false
+	}
+	return end == len(s) || isSpace(s[end]) || s[end] == '('
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
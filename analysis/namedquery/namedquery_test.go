@@ -0,0 +1,25 @@
+package namedquery_test
+
+import (
+	"testing"
+
+	"github.com/anton7r/orava/analysis/namedquery"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), namedquery.Analyzer, "a")
+}
+
+func TestAnalyzerFlags(t *testing.T) {
+	flags := map[string]string{"delim": "?", "placeholder": "question", "separator": "__", "mapper": "lower"}
+	for name, value := range flags {
+		defaultValue := namedquery.Analyzer.Flags.Lookup(name).DefValue
+		if err := namedquery.Analyzer.Flags.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+		defer namedquery.Analyzer.Flags.Set(name, defaultValue) // nolint: errcheck
+	}
+
+	analysistest.Run(t, analysistest.TestData(), namedquery.Analyzer, "b")
+}
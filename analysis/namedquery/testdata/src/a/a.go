@@ -0,0 +1,34 @@
+package a
+
+import (
+	"context"
+
+	"github.com/anton7r/orava/pgxquery"
+)
+
+type Address struct {
+	City string
+}
+
+type User struct {
+	ID      int64 `db:"user_id"`
+	Name    string
+	Address Address
+}
+
+func queries(ctx context.Context, api *pgxquery.API, db pgxquery.Querier) {
+	var user User
+	var users []*User
+
+	_ = api.GetNamed(ctx, db, &user, `SELECT user_id, name, a.city AS "address.city" FROM users WHERE user_id = :user_id`, &user)
+	_ = api.GetNamed(ctx, db, &user, `SELECT u.user_id, u.name AS name FROM users u WHERE name = :nmae`, &user) // want `field 'nmae' was not found from 'User' struct`
+	_ = api.SelectNamed(ctx, db, &users, `SELECT user_id, email FROM users WHERE name = :name`, &user)          // want `column 'email' has no corresponding field in 'User' struct`
+	_ = api.SelectNamed(ctx, db, &users, `SELECT * FROM users WHERE name = :name`, map[string]interface{}{})
+	_ = api.SelectNamed(ctx, db, &users, `SELECT count(*) FROM {{table}}`, &user)                                 // want `field 'table' was not found from 'User' struct`
+	_, _ = api.ExecNamed(ctx, db, `DELETE FROM users WHERE user_id = :id`, user)                                  // want `field 'id' was not found from 'User' struct`
+	_, _ = api.PrepareNamed(`SELECT * FROM users WHERE city = :address.city AND name = :name`, User{}, Address{}) // want `field 'address.city' was not found from 'Address' struct` `field 'name' was not found from 'Address' struct`
+	_, _ = api.PrepareNamed(`SELECT * FROM {{table`)                                                              // want `invalid named query: orava ident: unterminated identifier at '{{table'`
+
+	query := "SELECT * FROM users WHERE x = :x"
+	_ = api.GetNamed(ctx, db, &user, query, &user)
+}
@@ -0,0 +1,24 @@
+package b
+
+import (
+	"context"
+
+	"github.com/anton7r/orava/pgxquery"
+)
+
+type Address struct {
+	City string
+}
+
+type User struct {
+	UserID  int64
+	Name    string
+	Address Address
+}
+
+func queries(ctx context.Context, api *pgxquery.API, db pgxquery.Querier) {
+	var users []*User
+
+	_ = api.SelectNamed(ctx, db, &users, `SELECT userid, name, address__city FROM users WHERE name = ?name`, &User{})
+	_ = api.SelectNamed(ctx, db, &users, `SELECT user_id, name FROM users WHERE userid = ?userid`, &User{}) // want `column 'user_id' has no corresponding field in 'User' struct`
+}
@@ -0,0 +1,30 @@
+// Package pgxquery is a stub of the orava pgxquery package with the same method signatures.
+package pgxquery
+
+import "context"
+
+type Querier interface{}
+
+type API struct{}
+
+type PreparedQuery struct{}
+
+func (api *API) SelectNamed(ctx context.Context, db Querier, dst interface{}, query string, arg interface{}) error {
+	return nil
+}
+
+func (api *API) GetNamed(ctx context.Context, db Querier, dst interface{}, query string, arg interface{}) error {
+	return nil
+}
+
+func (api *API) ExecNamed(ctx context.Context, db Querier, query string, arg interface{}) (string, error) {
+	return "", nil
+}
+
+func (api *API) PrepareNamed(query string, assertableStruct ...interface{}) (*PreparedQuery, error) {
+	return nil, nil
+}
+
+func (pq *PreparedQuery) GetNamed(ctx context.Context, db Querier, dst interface{}, arg interface{}) error {
+	return nil
+}
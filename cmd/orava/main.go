@@ -0,0 +1,159 @@
+// Command orava is the command line tool of the orava library.
+//
+// Usage:
+//
+//	orava gen [flags] <query files>
+//
+// The gen command reads the named queries of the .sql files, resolves their
+// "-- params: <Type>" and "-- result: <Type>" annotations from the Go package
+// in the -pkg directory and writes typed functions calling pgxquery or sqlquery.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anton7r/orava/dbquery"
+	"github.com/anton7r/orava/internal/gen"
+	"github.com/anton7r/orava/internal/typemap"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "gen" {
+		fmt.Fprintln(os.Stderr, "usage: orava gen [flags] <query files>")
+		os.Exit(2)
+	}
+
+	if err := runGen(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runGen(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	pkgDir := flags.String("pkg", ".", "directory of the Go package the code is generated into")
+	out := flags.String("out", "queries.orava.go", "name of the generated file inside the package directory")
+	driver := flags.String("driver", string(gen.DriverPgx), "orava package the generated code calls: pgx or sql")
+	placeholder := flags.String("placeholder", "dollar", "placeholder format of the driver: dollar or question")
+	delim := flags.String("delim", ":", "delimiter of the named params")
+	tagKey := flags.String("tag", typemap.DefaultMapper.TagKey, "struct tag key used for mapping columns")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("orava gen: no query files given")
+	}
+
+	delimRune, width := utf8.DecodeRuneInString(*delim)
+	if width == 0 || width != len(*delim) {
+		return errors.Errorf("orava gen: delimiter must be a single character, got '%s'", *delim)
+	}
+	var driverDelim dbquery.DriverDelim
+	switch *placeholder {
+	case "dollar":
+		driverDelim = dbquery.SequentialDollarDelim
+	case "question":
+		driverDelim = dbquery.QuestionDelim
+	default:
+		return errors.Errorf("orava gen: unknown placeholder format '%s'", *placeholder)
+	}
+
+	api, err := dbquery.NewAPI(dbquery.WithLexer(delimRune, driverDelim), dbquery.WithStructTagKey(*tagKey))
+	if err != nil {
+		return err
+	}
+
+	var defs []dbquery.QueryDef
+	for _, pattern := range flags.Args() {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return errors.Wrap(err, "orava gen")
+		}
+		if len(files) == 0 {
+			return errors.Errorf("orava gen: no files match '%s'", pattern)
+		}
+		for _, file := range files {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				return errors.Wrap(err, "orava gen")
+			}
+			fileDefs, err := dbquery.ParseQueries(filepath.Base(file), string(src))
+			if err != nil {
+				return err
+			}
+			defs = append(defs, fileDefs...)
+		}
+	}
+
+	if err := dbquery.CheckDuplicateQueries(defs); err != nil {
+		return err
+	}
+
+	pkg, err := loadPackage(*pkgDir, *out)
+	if err != nil {
+		return err
+	}
+
+	mapper := typemap.DefaultMapper
+	mapper.TagKey = *tagKey
+
+	src, err := gen.Generate(gen.Config{
+		Pkg:     pkg,
+		Driver:  gen.Driver(*driver),
+		Queries: defs,
+		API:     api,
+		Mapper:  mapper,
+	})
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(os.WriteFile(filepath.Join(*pkgDir, *out), src, 0o644), "orava gen")
+}
+
+// loadPackage type checks the non-test Go files of the directory, leaving out the previously generated file.
+// Imports are type checked from source, so that the export data format of the toolchain does not matter.
+func loadPackage(dir string, generated string) (*types.Package, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return info.Name() != generated && !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "orava gen: parse package")
+	}
+	if len(pkgs) != 1 {
+		return nil, errors.Errorf("orava gen: expected exactly one package in '%s', got %d", dir, len(pkgs))
+	}
+
+	var files []*ast.File
+	var name string
+	for pkgName, pkg := range pkgs {
+		name = pkgName
+		for _, file := range pkg.Files {
+			files = append(files, file)
+		}
+	}
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		// Errors are ignored, only the struct types are needed and
+		// they can be resolved even if the rest of the package does not compile.
+		Error: func(error) {},
+	}
+	pkg, _ := conf.Check(name, fset, files, nil)
+	if pkg == nil {
+		return nil, errors.Errorf("orava gen: could not type check the package in '%s'", dir)
+	}
+	return pkg, nil
+}
@@ -0,0 +1,555 @@
+package dbquery
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// aggregatePlan describes how the columns of a row are scanned into a struct
+// that is merged by its primary key, and into the elements of its nested slices.
+type aggregatePlan struct {
+	structType reflect.Type
+	columns    []aggregateColumn
+	keyColumns []int
+	children   []*aggregateChild
+	pointers   *nestedPointers
+}
+
+type aggregateColumn struct {
+	name       string
+	rowIndex   int
+	fieldIndex []int
+	groups     []int
+	converted  bool
+	nullZero   bool
+}
+
+type aggregateChild struct {
+	fieldIndex   []int
+	elementByPtr bool
+	plan         *aggregatePlan
+}
+
+// aggregateSlice keeps track of the elements already appended to a slice by their primary keys.
+type aggregateSlice struct {
+	seen     map[interface{}]int
+	elements []*aggregateElement
+}
+
+type aggregateElement struct {
+	children []*aggregateSlice
+}
+
+func newAggregateSlice() *aggregateSlice {
+	return &aggregateSlice{seen: map[interface{}]int{}}
+}
+
+// WithPrimaryKey declares the primary key columns of the struct type of the example value.
+// It is an alternative to tagging the fields with the pk option, e.g. `db:"id,pk"`.
+// See API.ScanAll for how the primary keys are used.
+func WithPrimaryKey(example interface{}, columns ...string) APIOption {
+	return func(api *API) {
+		structType := reflect.TypeOf(example)
+		for structType.Kind() == reflect.Ptr {
+			structType = structType.Elem()
+		}
+		if api.primaryKeys == nil {
+			api.primaryKeys = map[reflect.Type][]string{}
+		}
+		api.primaryKeys[structType] = columns
+	}
+}
+
+// tagOptions returns the options following the column name in the struct tag of the field
+func (api *API) tagOptions(field reflect.StructField) []string {
+	tag, ok := field.Tag.Lookup(api.structTagKey)
+	if !ok {
+		return nil
+	}
+	return strings.Split(tag, ",")[1:]
+}
+
+func hasTagOption(options []string, option string) bool {
+	for _, o := range options {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// primaryKeyColumns returns the columns declared as the primary key of the struct type
+func (api *API) primaryKeyColumns(structType reflect.Type) []string {
+	if columns, ok := api.primaryKeys[structType]; ok {
+		return columns
+	}
+
+	var columns []string
+	for column, fieldIndex := range api.getColumnToFieldIndexMap(structType) {
+		if hasTagOption(api.tagOptions(structType.FieldByIndex(fieldIndex)), "pk") {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// sliceFields returns the fields of the struct type that are slices of structs, by their column prefixes
+func (api *API) sliceFields(structType reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" || field.Type.Kind() != reflect.Slice {
+			continue
+		}
+		elemType := field.Type.Elem()
+		if elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		if elemType.Kind() != reflect.Struct || api.isScannableType(field.Type.Elem()) {
+			continue
+		}
+		dbTag, dbTagPresent := field.Tag.Lookup(api.structTagKey)
+		columnPart := strings.Split(dbTag, ",")[0]
+		if columnPart == "-" {
+			continue
+		}
+		if !dbTagPresent {
+			columnPart = api.fieldMapperFn(field.Name)
+		}
+		fields[columnPart] = field
+	}
+	return fields
+}
+
+type aggregatedKey struct {
+	structType reflect.Type
+}
+
+type aggregatePlanKey structPlanKey
+
+// Aggregated reports whether the rows scanned into the struct type are merged into its nested slices by its primary key,
+// see ScanAll. The rows of such a struct can span the chunks of the rows that are scanned a chunk at a time.
+func (api *API) Aggregated(structType reflect.Type) bool {
+	return api.isAggregated(structType)
+}
+
+// isAggregated reports whether the rows scanned into the struct type are merged by its primary key,
+// the answer is cached along with the struct plans of the API.
+func (api *API) isAggregated(structType reflect.Type) bool {
+	key := aggregatedKey{structType: structType}
+	if aggregated, found := api.structPlans.Load(key); found {
+		return aggregated.(bool)
+	}
+	aggregated := structType.Kind() == reflect.Struct &&
+		len(api.sliceFields(structType)) > 0 &&
+		len(api.primaryKeyColumns(structType)) > 0
+	api.structPlans.Store(key, aggregated)
+	return aggregated
+}
+
+// getAggregatePlan returns the plan for aggregating the columns into the struct type from the cache of the API,
+// building it if it's not there yet.
+func (api *API) getAggregatePlan(structType reflect.Type, columns []string) (*aggregatePlan, error) {
+	key := aggregatePlanKey{structType: structType, columns: strings.Join(columns, "\x00")}
+	if plan, found := api.structPlans.Load(key); found {
+		return plan.(*aggregatePlan), nil
+	}
+	if len(columns) == 0 {
+		return nil, errors.Errorf("scany: no columns to aggregate into %v", structType)
+	}
+
+	rowIndexes := make([]int, len(columns))
+	for i := range columns {
+		rowIndexes[i] = i
+	}
+	plan, err := api.newAggregatePlan(structType, "", columns, rowIndexes)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	api.structPlans.Store(key, plan)
+	return plan, nil
+}
+
+// newAggregatePlan builds the plan for the columns, which names are relative to the struct type
+// and follow the prefix in the row, and rowIndexes are their positions in the row.
+func (api *API) newAggregatePlan(structType reflect.Type, prefix string, columns []string, rowIndexes []int) (*aggregatePlan, error) {
+	plan := &aggregatePlan{structType: structType}
+	used := make([]bool, len(columns))
+
+	for slicePrefix, field := range api.sliceFields(structType) {
+		var childColumns []string
+		var childIndexes []int
+		for i, column := range columns {
+			if strings.HasPrefix(column, slicePrefix+api.columnSeparator) {
+				childColumns = append(childColumns, column[len(slicePrefix)+len(api.columnSeparator):])
+				childIndexes = append(childIndexes, rowIndexes[i])
+				used[i] = true
+			}
+		}
+		if len(childColumns) == 0 {
+			continue
+		}
+
+		elemType := field.Type.Elem()
+		elementByPtr := elemType.Kind() == reflect.Ptr
+		if elementByPtr {
+			elemType = elemType.Elem()
+		}
+		childPlan, err := api.newAggregatePlan(elemType, prefix+slicePrefix+api.columnSeparator, childColumns, childIndexes)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		plan.children = append(plan.children, &aggregateChild{
+			fieldIndex:   field.Index,
+			elementByPtr: elementByPtr,
+			plan:         childPlan,
+		})
+	}
+
+	plan.pointers = newNestedPointers(rowIndexes[len(rowIndexes)-1] + 1)
+	columnToFieldIndex := api.getColumnToFieldIndexMap(structType)
+	for i, column := range columns {
+		if used[i] {
+			continue
+		}
+		fieldIndex, ok := columnToFieldIndex[column]
+		if !ok {
+			if api.allowUnknownColumns {
+				continue
+			}
+			return nil, errors.Errorf(
+				"scany: column: '%s': no corresponding field found, or it's unexported in %v",
+				column, structType,
+			)
+		}
+		field := structType.FieldByIndex(fieldIndex)
+		conv, _ := api.scanConverterOf(field.Type)
+		plan.columns = append(plan.columns, aggregateColumn{
+			name:       prefix + column,
+			rowIndex:   rowIndexes[i],
+			fieldIndex: fieldIndex,
+			groups:     plan.pointers.add(rowIndexes[i], structType, fieldIndex),
+			converted:  conv != nil,
+			nullZero:   api.nullZero || hasTagOption(api.tagOptions(field), "nullzero"),
+		})
+	}
+
+	keyColumns := api.primaryKeyColumns(structType)
+	if len(keyColumns) == 0 {
+		// Identical child rows, like two equal order lines, can only be told apart by a primary key.
+		return nil, errors.Errorf(
+			"scany: %v has no primary key, tag its key fields with the pk option or declare it with WithPrimaryKey",
+			structType,
+		)
+	}
+	for _, keyColumn := range keyColumns {
+		found := false
+		for i, column := range columns {
+			if column == keyColumn && !used[i] {
+				plan.keyColumns = append(plan.keyColumns, rowIndexes[i])
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("scany: primary key column '%s' of %v is missing from the rows", keyColumn, structType)
+		}
+	}
+
+	return plan, nil
+}
+
+// aggregateRows scans all of the rows into the slice merging the rows with the same primary key
+// into a single element, and appending the nested columns into the elements of its slice fields.
+func (api *API) aggregateRows(sliceMeta *sliceDestinationMeta, rows Rows) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return errors.Wrap(err, "scany: get rows columns")
+	}
+	if api.strictScan {
+		if err := api.validateAggregatedColumns(sliceMeta.elementBaseType, columns, api.allowUnknownColumns); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	plan, err := api.getAggregatePlan(sliceMeta.elementBaseType, columns)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	holders := plan.newHolders(len(columns))
+	scans := make([]interface{}, len(holders))
+	for i, holder := range holders {
+		scans[i] = holder.Interface()
+	}
+
+	state := newAggregateSlice()
+	for rows.Next() {
+		if err := rows.Scan(scans...); err != nil {
+			return errors.Wrap(err, "scany: scan row into aggregated struct fields")
+		}
+		if err := api.merge(plan, sliceMeta.val, sliceMeta.elementByPtr, state, holders, true /* root */); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// newHolders creates the values the columns are scanned into before they are merged into the destination,
+// they are able to hold NULL, so that missing child rows of outer joins can be detected.
+func (plan *aggregatePlan) newHolders(count int) []reflect.Value {
+	holders := make([]reflect.Value, count)
+	plan.fillHolders(holders)
+	for i, holder := range holders {
+		if !holder.IsValid() {
+			// Unknown columns are discarded.
+			holders[i] = reflect.New(interfaceType)
+		}
+	}
+	return holders
+}
+
+func (plan *aggregatePlan) fillHolders(holders []reflect.Value) {
+	for _, column := range plan.columns {
+		if column.converted {
+			holders[column.rowIndex] = reflect.New(interfaceType)
+			continue
+		}
+		holders[column.rowIndex] = reflect.New(nullableType(plan.structType.FieldByIndex(column.fieldIndex).Type))
+	}
+	for _, child := range plan.children {
+		child.plan.fillHolders(holders)
+	}
+}
+
+// nullableType returns a type that can hold a NULL value in place of the field type
+func nullableType(fieldType reflect.Type) reflect.Type {
+	switch fieldType.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return fieldType
+	}
+	return reflect.PtrTo(fieldType)
+}
+
+func isNullHolder(holder reflect.Value) bool {
+	return holder.Elem().IsNil()
+}
+
+// setFromHolder sets the scanned value of the holder into the field
+func setFromHolder(field reflect.Value, holder reflect.Value) {
+	value := holder.Elem()
+	if value.Type() == field.Type() {
+		field.Set(value)
+		return
+	}
+	if value.IsNil() {
+		field.Set(reflect.Zero(field.Type()))
+		return
+	}
+	field.Set(value.Elem())
+}
+
+// validateAggregatedColumns validates the columns of each struct of an aggregated destination like validateColumns,
+// the columns of the nested slices are validated against their element types and reported by their full names.
+func (api *API) validateAggregatedColumns(structType reflect.Type, columns []string, allowUnknownColumns bool) error {
+	dstErr := &DestinationError{Type: structType}
+	api.collectAggregatedMismatches(structType, "", columns, allowUnknownColumns, dstErr)
+	if len(dstErr.UnknownColumns) == 0 && len(dstErr.MissingColumns) == 0 {
+		return nil
+	}
+	sort.Strings(dstErr.UnknownColumns)
+	sort.Strings(dstErr.MissingColumns)
+	return dstErr
+}
+
+func (api *API) collectAggregatedMismatches(
+	structType reflect.Type, prefix string, columns []string, allowUnknownColumns bool, dstErr *DestinationError,
+) {
+	sliceFields := api.sliceFields(structType)
+	childColumns := make(map[string][]string, len(sliceFields))
+	var ownColumns []string
+	for _, column := range columns {
+		nested := false
+		for slicePrefix := range sliceFields {
+			if strings.HasPrefix(column, slicePrefix+api.columnSeparator) {
+				childColumns[slicePrefix] = append(childColumns[slicePrefix], column[len(slicePrefix)+len(api.columnSeparator):])
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			ownColumns = append(ownColumns, column)
+		}
+	}
+
+	if err := api.validateColumns(structType, ownColumns, nil, allowUnknownColumns); err != nil {
+		var levelErr *DestinationError
+		if !errors.As(err, &levelErr) {
+			return
+		}
+		for _, column := range levelErr.UnknownColumns {
+			dstErr.UnknownColumns = append(dstErr.UnknownColumns, prefix+column)
+		}
+		for _, column := range levelErr.MissingColumns {
+			if _, isSlice := sliceFields[column]; !isSlice {
+				dstErr.MissingColumns = append(dstErr.MissingColumns, prefix+column)
+			}
+		}
+	}
+
+	for slicePrefix, field := range sliceFields {
+		elemType := field.Type.Elem()
+		if elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		childPrefix := prefix + slicePrefix + api.columnSeparator
+		if len(childColumns[slicePrefix]) == 0 {
+			// Not recursing into the element type without columns, which may be the struct type itself.
+			childSlices := api.sliceFields(elemType)
+			for _, column := range api.leafColumns(elemType) {
+				if _, isSlice := childSlices[column]; !isSlice {
+					dstErr.MissingColumns = append(dstErr.MissingColumns, childPrefix+column)
+				}
+			}
+			continue
+		}
+		api.collectAggregatedMismatches(elemType, childPrefix, childColumns[slicePrefix], allowUnknownColumns, dstErr)
+	}
+}
+
+// setAggregatedField sets the scanned value of the holder into the field,
+// NULL is only accepted by the fields that can hold it the same way as when scanning into a struct.
+func setAggregatedField(field reflect.Value, holder reflect.Value, column aggregateColumn) error {
+	if isNullHolder(holder) && nullableType(field.Type()) != field.Type() {
+		if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+			// Scanners like sql.NullString hold the NULL themselves.
+			return errors.Wrapf(scanner.Scan(nil), "scany: scan row into aggregated struct fields: column: '%s'", column.name)
+		}
+		if !column.nullZero {
+			return errors.Errorf(
+				"scany: scan row into aggregated struct fields: column: '%s': can't scan NULL into %v field",
+				column.name, field.Type(),
+			)
+		}
+	}
+	setFromHolder(field, holder)
+	return nil
+}
+
+func (plan *aggregatePlan) allNull(holders []reflect.Value) bool {
+	for _, column := range plan.columns {
+		if !isNullHolder(holders[column.rowIndex]) {
+			return false
+		}
+	}
+	return true
+}
+
+// nullKey stands for a NULL primary key column.
+type nullKey struct{}
+
+// bytesKey keeps the []byte columns apart from the string columns with the same contents.
+type bytesKey string
+
+// formattedKey holds the values of the types that can't be compared, by their type and formatted value.
+type formattedKey struct {
+	valueType reflect.Type
+	value     string
+}
+
+// compositeKey chains the values of primary keys with more than one column.
+type compositeKey struct {
+	head interface{}
+	tail interface{}
+}
+
+// key returns the primary key of the row as a comparable value, which keeps the types of the columns,
+// so that the integer 1 and the string "1" are different keys.
+func (plan *aggregatePlan) key(holders []reflect.Value) interface{} {
+	var key interface{}
+	for i := len(plan.keyColumns) - 1; i >= 0; i-- {
+		columnKey := keyOf(holders[plan.keyColumns[i]].Elem())
+		if len(plan.keyColumns) == 1 {
+			return columnKey
+		}
+		key = compositeKey{head: columnKey, tail: key}
+	}
+	return key
+}
+
+func keyOf(value reflect.Value) interface{} {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nullKey{}
+		}
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+		return bytesKey(value.Bytes())
+	}
+	if !value.Type().Comparable() {
+		return formattedKey{valueType: value.Type(), value: fmt.Sprintf("%v", value.Interface())}
+	}
+	return value.Interface()
+}
+
+// merge finds the element of the row from the slice by the primary key, appending it if it's new,
+// and merges the columns of the nested slices into it.
+func (api *API) merge(plan *aggregatePlan, sliceVal reflect.Value, elementByPtr bool, state *aggregateSlice, holders []reflect.Value, root bool) error {
+	if !root && plan.allNull(holders) {
+		// Outer joins produce NULL columns when there are no child rows.
+		return nil
+	}
+
+	key := plan.key(holders)
+
+	index, found := state.seen[key]
+	if !found {
+		elemPtr := reflect.New(plan.structType)
+		present := plan.pointers.present(holders, nil)
+		for _, column := range plan.columns {
+			if !allPresent(column.groups, present) {
+				// Nested structs behind pointers are left nil when all of their columns are NULL.
+				continue
+			}
+			initializeNested(elemPtr.Elem(), column.fieldIndex)
+			field := elemPtr.Elem().FieldByIndex(column.fieldIndex)
+			if column.converted {
+				value, err := api.convertScanned(field.Type(), holders[column.rowIndex].Elem().Interface())
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				field.Set(value)
+				continue
+			}
+			if err := setAggregatedField(field, holders[column.rowIndex], column); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if elementByPtr {
+			sliceVal.Set(reflect.Append(sliceVal, elemPtr))
+		} else {
+			sliceVal.Set(reflect.Append(sliceVal, elemPtr.Elem()))
+		}
+
+		element := &aggregateElement{children: make([]*aggregateSlice, len(plan.children))}
+		for i := range plan.children {
+			element.children[i] = newAggregateSlice()
+		}
+		index = len(state.elements)
+		state.seen[key] = index
+		state.elements = append(state.elements, element)
+	}
+
+	elemVal := reflect.Indirect(sliceVal.Index(index))
+	for i, child := range plan.children {
+		childSlice := elemVal.FieldByIndex(child.fieldIndex)
+		if err := api.merge(child.plan, childSlice, child.elementByPtr, state.elements[index].children[i], holders, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+package dbquery
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testItem struct {
+	ID   int64 `db:"id,pk"`
+	Name string
+}
+
+type testOrder struct {
+	ID    int64 `db:"id,pk"`
+	Total int64
+	Items []testItem
+}
+
+type testCustomer struct {
+	ID     int64 `db:"id,pk"`
+	Name   string
+	Orders []*testOrder
+}
+
+func TestScanAllAggregated(t *testing.T) {
+	rows := newFakeRows(
+		[]string{"id", "name", "orders.id", "orders.total", "orders.items.id", "orders.items.name"},
+		[]interface{}{int64(1), "bob", int64(10), int64(100), int64(7), "hat"},
+		[]interface{}{int64(1), "bob", int64(10), int64(100), int64(8), "scarf"},
+		[]interface{}{int64(1), "bob", int64(11), int64(50), nil, nil},
+		[]interface{}{int64(2), "alice", nil, nil, nil, nil},
+		[]interface{}{int64(3), "carol", int64(12), int64(20), int64(7), "hat"},
+	)
+
+	var got []testCustomer
+	if err := DefaultAPI.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := []testCustomer{
+		{ID: 1, Name: "bob", Orders: []*testOrder{
+			{ID: 10, Total: 100, Items: []testItem{{ID: 7, Name: "hat"}, {ID: 8, Name: "scarf"}}},
+			{ID: 11, Total: 50},
+		}},
+		{ID: 2, Name: "alice"},
+		{ID: 3, Name: "carol", Orders: []*testOrder{
+			{ID: 12, Total: 20, Items: []testItem{{ID: 7, Name: "hat"}}},
+		}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+}
+
+func TestScanOneAggregated(t *testing.T) {
+	type Tag struct {
+		Label string `db:"label,pk"`
+	}
+
+	type Post struct {
+		PostID int64
+		Title  string
+		Tags   []Tag
+	}
+
+	api, err := NewAPI(WithPrimaryKey(Post{}, "post_id"))
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	rows := newFakeRows(
+		[]string{"post_id", "title", "tags.label"},
+		[]interface{}{int64(1), "Hello", "go"},
+		[]interface{}{int64(1), "Hello", "sql"},
+	)
+
+	var got Post
+	if err := api.ScanOne(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := Post{PostID: 1, Title: "Hello", Tags: []Tag{{Label: "go"}, {Label: "sql"}}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+
+	rows = newFakeRows([]string{"post_id", "title", "tags.label"})
+	if err := api.ScanOne(&got, rows); !NotFound(err) {
+		t.Errorf("Expected not found error, but got: %v", err)
+	}
+}
+
+func TestScanAllAggregatedMissingKey(t *testing.T) {
+	rows := newFakeRows([]string{"name", "orders.id"}, []interface{}{"bob", int64(10)})
+
+	var got []testCustomer
+	err := DefaultAPI.ScanAll(&got, rows)
+
+	expectedErr := "scany: primary key column 'id' of dbquery.testCustomer is missing from the rows"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected error: '%s', but got: '%v'", expectedErr, err)
+	}
+}
+
+func TestScanAllAggregatedNestedPointer(t *testing.T) {
+	type Line struct {
+		ID      int64 `db:"id,pk"`
+		Address *testAddress
+	}
+
+	type Shipment struct {
+		ID    int64 `db:"id,pk"`
+		Lines []Line
+	}
+
+	rows := newFakeRows(
+		[]string{"id", "lines.id", "lines.address.city", "lines.address.street"},
+		[]interface{}{int64(1), int64(10), "Helsinki", "Mannerheimintie"},
+		[]interface{}{int64(1), int64(11), nil, nil},
+	)
+
+	var got []Shipment
+	if err := DefaultAPI.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := []Shipment{
+		{ID: 1, Lines: []Line{
+			{ID: 10, Address: &testAddress{City: "Helsinki", Street: "Mannerheimintie"}},
+			{ID: 11},
+		}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+}
+
+func TestScanAllAggregatedSiblingSlices(t *testing.T) {
+	type Tag struct {
+		Label string `db:"label,pk"`
+	}
+
+	type Comment struct {
+		Body string `db:"body,pk"`
+	}
+
+	type Post struct {
+		ID       int64 `db:"id,pk"`
+		Tags     []Tag
+		Comments []Comment
+	}
+
+	rows := newFakeRows(
+		[]string{"id", "tags.label", "comments.body"},
+		[]interface{}{int64(1), "go", "first"},
+		[]interface{}{int64(1), "go", "second"},
+		[]interface{}{int64(1), "sql", "first"},
+		[]interface{}{int64(1), "sql", "second"},
+		[]interface{}{int64(2), nil, "only"},
+	)
+
+	var got []Post
+	if err := DefaultAPI.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := []Post{
+		{ID: 1, Tags: []Tag{{Label: "go"}, {Label: "sql"}}, Comments: []Comment{{Body: "first"}, {Body: "second"}}},
+		{ID: 2, Comments: []Comment{{Body: "only"}}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+}
+
+func TestScanAllAggregatedTypedKeys(t *testing.T) {
+	type Item struct {
+		Name string `db:"name,pk"`
+	}
+
+	type Group struct {
+		Key   interface{} `db:"key,pk"`
+		Items []Item
+	}
+
+	rows := newFakeRows(
+		[]string{"key", "items.name"},
+		[]interface{}{int64(1), "a"},
+		[]interface{}{"1", "b"},
+		[]interface{}{[]byte("1"), "c"},
+		[]interface{}{int64(1), "d"},
+	)
+
+	var got []Group
+	if err := DefaultAPI.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := []Group{
+		{Key: int64(1), Items: []Item{{Name: "a"}, {Name: "d"}}},
+		{Key: "1", Items: []Item{{Name: "b"}}},
+		{Key: []byte("1"), Items: []Item{{Name: "c"}}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+}
+
+func TestScanAllAggregatedIdenticalChildRows(t *testing.T) {
+	rows := newFakeRows(
+		[]string{"id", "total", "items.id", "items.name"},
+		[]interface{}{int64(10), int64(100), int64(7), "hat"},
+		[]interface{}{int64(10), int64(100), int64(8), "hat"},
+	)
+
+	var got []testOrder
+	if err := DefaultAPI.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := []testOrder{{ID: 10, Total: 100, Items: []testItem{{ID: 7, Name: "hat"}, {ID: 8, Name: "hat"}}}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+}
+
+func TestScanAllAggregatedNestedWithoutPrimaryKey(t *testing.T) {
+	type Line struct {
+		Name string
+	}
+
+	type Invoice struct {
+		ID    int64 `db:"id,pk"`
+		Lines []Line
+	}
+
+	rows := newFakeRows([]string{"id", "lines.name"}, []interface{}{int64(1), "hat"})
+
+	var got []Invoice
+	err := DefaultAPI.ScanAll(&got, rows)
+
+	expectedErr := "scany: dbquery.Line has no primary key, tag its key fields with the pk option or declare it with WithPrimaryKey"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected error: '%s', but got: '%v'", expectedErr, err)
+	}
+}
+
+func TestScanAllAggregatedNoColumns(t *testing.T) {
+	rows := newFakeRows([]string{})
+
+	var got []testCustomer
+	err := DefaultAPI.ScanAll(&got, rows)
+
+	expectedErr := "scany: no columns to aggregate into dbquery.testCustomer"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected error: '%s', but got: '%v'", expectedErr, err)
+	}
+}
+
+func TestScanAllAggregatedStrict(t *testing.T) {
+	api, err := NewAPI(WithStrictScan())
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	rows := newFakeRows(
+		[]string{"id", "name", "orders.id", "orders.items.id", "orders.items.color"},
+		[]interface{}{int64(1), "bob", int64(10), int64(7), "red"},
+	)
+
+	var got []testCustomer
+	err = api.ScanAll(&got, rows)
+
+	var dstErr *DestinationError
+	if !errors.As(err, &dstErr) {
+		t.Fatalf("Expected a destination error, but got: %v", err)
+	}
+	expectedUnknown := []string{"orders.items.color"}
+	if !reflect.DeepEqual(dstErr.UnknownColumns, expectedUnknown) {
+		t.Errorf("Expected: %v, but got: %v", expectedUnknown, dstErr.UnknownColumns)
+	}
+	expectedMissing := []string{"orders.items.name", "orders.total"}
+	if !reflect.DeepEqual(dstErr.MissingColumns, expectedMissing) {
+		t.Errorf("Expected: %v, but got: %v", expectedMissing, dstErr.MissingColumns)
+	}
+}
+
+func TestScanAllAggregatedNull(t *testing.T) {
+	type Note struct {
+		ID    int64          `db:"id,pk"`
+		Text  string         `db:"text"`
+		Zero  string         `db:"zero,nullzero"`
+		Color sql.NullString `db:"color"`
+	}
+
+	type Board struct {
+		ID    int64 `db:"id,pk"`
+		Notes []Note
+	}
+
+	columns := []string{"id", "notes.id", "notes.text", "notes.zero", "notes.color"}
+	rows := newFakeRows(columns, []interface{}{int64(1), int64(10), "hi", nil, nil})
+
+	var got []Board
+	if err := DefaultAPI.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+	expected := []Board{{ID: 1, Notes: []Note{{ID: 10, Text: "hi"}}}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+
+	rows = newFakeRows(columns, []interface{}{int64(1), int64(10), nil, nil, nil})
+	err := DefaultAPI.ScanAll(&got, rows)
+
+	expectedErr := "scany: scan row into aggregated struct fields: column: 'notes.text': can't scan NULL into string field"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected error: '%s', but got: '%v'", expectedErr, err)
+	}
+}
@@ -0,0 +1,251 @@
+package dbquery
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// ScanColumn iterates all rows to the end and returns the values of their single column, for example:
+//
+//	ids, err := dbquery.ScanColumn[int64](api, rows) // SELECT id FROM users
+//
+// The values are scanned as they are even when T is a struct, like time.Time.
+// After iterating ScanColumn closes the rows, and propagates any errors that could pop up.
+func ScanColumn[T any](api *API, rows Rows) ([]T, error) {
+	defer rows.Close() // nolint: errcheck
+	rs := api.NewRowScanner(rows)
+	columns, err := rs.Columns()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(columns) != 1 {
+		return nil, errors.Errorf("scany: to scan a column, columns number must be exactly 1, got: %d", len(columns))
+	}
+	rs.scanFn = rs.scanPrimitive
+	rs.started = true
+
+	values := []T{}
+	for rows.Next() {
+		var value T
+		if err := rs.doScan(reflect.ValueOf(&value).Elem()); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		values = append(values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "scany: rows final error")
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, errors.Wrap(err, "scany: close rows after processing")
+	}
+	return values, nil
+}
+
+// DuplicateKeyPolicy decides what happens when more than one row has the same key
+// while scanning rows into a map.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeyError fails the scan on the first key that appears again, it's the default.
+	DuplicateKeyError DuplicateKeyPolicy = iota
+	// DuplicateKeyLastWins keeps the value of the last row with the key.
+	DuplicateKeyLastWins
+)
+
+// WithDuplicateKeys sets the policy for the keys that appear in more than one row
+// while scanning into a map with ScanAll or ScanIndexed.
+func WithDuplicateKeys(policy DuplicateKeyPolicy) APIOption {
+	return func(api *API) {
+		api.duplicateKeys = policy
+	}
+}
+
+// keyedRows hides the key column of the rows, which is scanned into the key
+// while the rest of the columns are scanned into the map value.
+type keyedRows struct {
+	Rows
+	keyIndex int
+	key      interface{}
+}
+
+func (kr *keyedRows) Columns() ([]string, error) {
+	columns, err := kr.Rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]string, 0, len(columns)-1)
+	visible = append(visible, columns[:kr.keyIndex]...)
+	return append(visible, columns[kr.keyIndex+1:]...), nil
+}
+
+func (kr *keyedRows) UnqualifiedColumns() ([]string, error) {
+	qualifiedRows, ok := kr.Rows.(QualifiedRows)
+	if !ok {
+		return nil, nil
+	}
+	columns, err := qualifiedRows.UnqualifiedColumns()
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]string, 0, len(columns)-1)
+	visible = append(visible, columns[:kr.keyIndex]...)
+	return append(visible, columns[kr.keyIndex+1:]...), nil
+}
+
+func (kr *keyedRows) Scan(dest ...interface{}) error {
+	scans := make([]interface{}, 0, len(dest)+1)
+	scans = append(scans, dest[:kr.keyIndex]...)
+	scans = append(scans, kr.key)
+	return kr.Rows.Scan(append(scans, dest[kr.keyIndex:]...)...)
+}
+
+var emptyStructType = reflect.TypeOf(struct{}{})
+
+// ScanIndexed is a package-level helper function that uses the DefaultAPI object.
+// See API.ScanIndexed for details.
+func ScanIndexed(dst interface{}, rows Rows, keyColumn string) error {
+	return errors.WithStack(DefaultAPI.ScanIndexed(dst, rows, keyColumn))
+}
+
+// ScanIndexed iterates all rows to the end and scans them into the destination map,
+// which should be a map of structs by value or by a pointer, like map[int64]*User.
+// The key of each row is the value of the struct field mapped to the key column,
+// or when there is no such field, the value of the key column which is then left out from the struct.
+// A key that appears in more than one row is handled as set by WithDuplicateKeys.
+// After iterating ScanIndexed closes the rows, and propagates any errors that could pop up.
+func (api *API) ScanIndexed(dst interface{}, rows Rows, keyColumn string) error {
+	defer rows.Close() // nolint: errcheck
+	mapVal, err := parseDestination(dst)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if mapVal.Kind() != reflect.Map {
+		return errors.Errorf("scany: destination must be a map, got: %v", mapVal.Type())
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return errors.Wrap(err, "scany: get rows columns")
+	}
+
+	keyIndex := -1
+	for i, column := range columns {
+		if column == keyColumn {
+			keyIndex = i
+		}
+	}
+	if keyIndex < 0 {
+		return errors.Errorf("scany: key column '%s' is missing from the rows", keyColumn)
+	}
+
+	mapType := mapVal.Type()
+	elemMeta := api.mapElementMeta(mapType)
+	if elemMeta.elementBaseType.Kind() == reflect.Struct {
+		if fieldIndex, ok := api.getColumnToFieldIndexMap(elemMeta.elementBaseType)[keyColumn]; ok {
+			fieldType := elemMeta.elementBaseType.FieldByIndex(fieldIndex).Type
+			if !fieldType.AssignableTo(mapType.Key()) {
+				return errors.Errorf(
+					"scany: key field of column '%s' of type %v is not assignable to the key of %v",
+					keyColumn, fieldType, mapType,
+				)
+			}
+			return errors.WithStack(api.scanKeyedRows(mapVal, elemMeta, rows, keyColumn, keyIndex, fieldIndex))
+		}
+	}
+	if len(columns) < 2 {
+		return errors.Errorf("scany: to scan into %v, columns number must be at least 2, got: %d", mapType, len(columns))
+	}
+	return errors.WithStack(api.scanKeyedRows(mapVal, elemMeta, rows, keyColumn, keyIndex, nil))
+}
+
+// processKeyedRows scans the rows into the map keyed by the first column.
+// When the map value is struct{} the map is a set of the values of the single column.
+func (api *API) processKeyedRows(mapVal reflect.Value, rows Rows) error {
+	mapType := mapVal.Type()
+	columns, err := rows.Columns()
+	if err != nil {
+		return errors.Wrap(err, "scany: get rows columns")
+	}
+
+	isSet := mapType.Elem() == emptyStructType
+	if isSet && len(columns) != 1 {
+		return errors.Errorf("scany: to scan into a set %v, columns number must be exactly 1, got: %d", mapType, len(columns))
+	}
+	if !isSet && len(columns) < 2 {
+		return errors.Errorf("scany: to scan into %v, columns number must be at least 2, got: %d", mapType, len(columns))
+	}
+
+	return errors.WithStack(api.scanKeyedRows(mapVal, api.mapElementMeta(mapType), rows, columns[0], 0, nil))
+}
+
+func (api *API) mapElementMeta(mapType reflect.Type) *sliceDestinationMeta {
+	elemMeta := &sliceDestinationMeta{elementBaseType: mapType.Elem()}
+	if elemMeta.elementBaseType.Kind() == reflect.Ptr && elemMeta.elementBaseType.Elem().Kind() == reflect.Struct &&
+		!api.isScannableType(elemMeta.elementBaseType) {
+		elemMeta.elementBaseType = elemMeta.elementBaseType.Elem()
+		elemMeta.elementByPtr = true
+	}
+	return elemMeta
+}
+
+// scanKeyedRows scans the rows into the map. The key is taken from the field of the value at keyField,
+// or if it's nil, scanned from the column at keyIndex which is hidden from the value.
+func (api *API) scanKeyedRows(
+	mapVal reflect.Value, elemMeta *sliceDestinationMeta, rows Rows, keyColumn string, keyIndex int, keyField []int,
+) error {
+	mapType := mapVal.Type()
+	isSet := mapType.Elem() == emptyStructType
+
+	// Make sure map is empty.
+	mapVal.Set(reflect.MakeMap(mapType))
+
+	kr := &keyedRows{Rows: rows, keyIndex: keyIndex}
+	rs := api.NewRowScanner(kr)
+	if keyField != nil {
+		rs = api.NewRowScanner(rows)
+	}
+	for rows.Next() {
+		key := reflect.New(mapType.Key())
+		elem := reflect.New(elemMeta.elementBaseType)
+		switch {
+		case isSet:
+			if err := rows.Scan(key.Interface()); err != nil {
+				return errors.Wrap(err, "scany: scan row into set")
+			}
+		case keyField != nil:
+			if err := rs.Scan(elem.Interface()); err != nil {
+				return errors.WithStack(err)
+			}
+			keyValue, err := elem.Elem().FieldByIndexErr(keyField)
+			if err != nil {
+				return errors.Wrapf(err, "scany: get key field of column '%s'", keyColumn)
+			}
+			key.Elem().Set(keyValue)
+		default:
+			kr.key = key.Interface()
+			if err := rs.Scan(elem.Interface()); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if api.duplicateKeys == DuplicateKeyError && mapVal.MapIndex(key.Elem()).IsValid() {
+			return errors.Errorf("scany: duplicate key '%v' in column '%s'", key.Elem().Interface(), keyColumn)
+		}
+		if elemMeta.elementByPtr {
+			mapVal.SetMapIndex(key.Elem(), elem)
+		} else {
+			mapVal.SetMapIndex(key.Elem(), elem.Elem())
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "scany: rows final error")
+	}
+
+	if err := rows.Close(); err != nil {
+		return errors.Wrap(err, "scany: close rows after processing")
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package dbquery
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanColumn(t *testing.T) {
+	rows := newFakeRows([]string{"id"}, []interface{}{int64(3)}, []interface{}{int64(1)})
+
+	got, err := ScanColumn[int64](DefaultAPI, rows)
+	if err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+	if !reflect.DeepEqual(got, []int64{3, 1}) {
+		t.Errorf("Expected: [3 1], but got: %v", got)
+	}
+	if !rows.closed {
+		t.Error("Expected rows to be closed")
+	}
+
+	rows = newFakeRows([]string{"id", "name"}, []interface{}{int64(1), "bob"})
+	if _, err := ScanColumn[int64](DefaultAPI, rows); err == nil {
+		t.Error("Expected an error for more than one column")
+	}
+}
+
+func TestScanAllSet(t *testing.T) {
+	rows := newFakeRows([]string{"id"}, []interface{}{int64(3)}, []interface{}{int64(1)})
+
+	var got map[int64]struct{}
+	if err := DefaultAPI.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := map[int64]struct{}{1: {}, 3: {}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %v, but got: %v", expected, got)
+	}
+}
+
+func TestScanAllKeyed(t *testing.T) {
+	rows := newFakeRows(
+		[]string{"id", "name", "address.city", "address.street"},
+		[]interface{}{int64(1), "bob", "Helsinki", "Mannerheimintie"},
+		[]interface{}{int64(2), "alice", "Turku", "Aurakatu"},
+	)
+
+	var got map[int64]*testUser
+	if err := DefaultAPI.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := map[int64]*testUser{
+		1: {Name: "bob", Address: testAddress{City: "Helsinki", Street: "Mannerheimintie"}},
+		2: {Name: "alice", Address: testAddress{City: "Turku", Street: "Aurakatu"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+
+	rows = newFakeRows(
+		[]string{"code", "name"},
+		[]interface{}{"FI", "Finland"},
+		[]interface{}{"SE", "Sweden"},
+		[]interface{}{"FI", "Suomi"},
+	)
+	var names map[string]string
+	err := DefaultAPI.ScanAll(&names, rows)
+	if err == nil || !strings.Contains(err.Error(), "duplicate key 'FI'") {
+		t.Errorf("Expected a duplicate key error, but got: %v", err)
+	}
+}
+
+func TestScanIndexed(t *testing.T) {
+	rows := newFakeRows(
+		[]string{"id", "name", "address.city", "address.street"},
+		[]interface{}{int64(1), "bob", "Helsinki", "Mannerheimintie"},
+		[]interface{}{int64(2), "alice", "Turku", "Aurakatu"},
+	)
+
+	var byID map[int64]*testUser
+	if err := ScanIndexed(&byID, rows, "id"); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := map[int64]*testUser{
+		1: {ID: 1, Name: "bob", Address: testAddress{City: "Helsinki", Street: "Mannerheimintie"}},
+		2: {ID: 2, Name: "alice", Address: testAddress{City: "Turku", Street: "Aurakatu"}},
+	}
+	if !reflect.DeepEqual(byID, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, byID)
+	}
+
+	// The key column without a field is left out from the value.
+	rows = newFakeRows(
+		[]string{"city", "street", "user_id"},
+		[]interface{}{"Helsinki", "Mannerheimintie", int64(1)},
+		[]interface{}{"Turku", "Aurakatu", int64(2)},
+	)
+
+	var byUser map[int64]testAddress
+	if err := ScanIndexed(&byUser, rows, "user_id"); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expectedAddresses := map[int64]testAddress{
+		1: {City: "Helsinki", Street: "Mannerheimintie"},
+		2: {City: "Turku", Street: "Aurakatu"},
+	}
+	if !reflect.DeepEqual(byUser, expectedAddresses) {
+		t.Errorf("Expected: %+v, but got: %+v", expectedAddresses, byUser)
+	}
+
+	rows = newFakeRows([]string{"id", "name"}, []interface{}{int64(1), "bob"})
+	if err := ScanIndexed(&byID, rows, "email"); err == nil {
+		t.Error("Expected an error for a missing key column")
+	}
+}
+
+func TestScanIndexedDuplicateKeys(t *testing.T) {
+	newRows := func() *fakeRows {
+		return newFakeRows(
+			[]string{"city", "street"},
+			[]interface{}{"Helsinki", "Mannerheimintie"},
+			[]interface{}{"Helsinki", "Aleksanterinkatu"},
+		)
+	}
+
+	var got map[string]testAddress
+	err := ScanIndexed(&got, newRows(), "city")
+	if err == nil || !strings.Contains(err.Error(), "duplicate key 'Helsinki'") {
+		t.Errorf("Expected a duplicate key error, but got: %v", err)
+	}
+
+	api, err := NewAPI(WithDuplicateKeys(DuplicateKeyLastWins))
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+	if err := api.ScanIndexed(&got, newRows(), "city"); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := map[string]testAddress{"Helsinki": {City: "Helsinki", Street: "Aleksanterinkatu"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+}
@@ -0,0 +1,105 @@
+package dbquery
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// scanConverter converts a value scanned from the database into a value of the registered type
+type scanConverter func(src interface{}) (reflect.Value, error)
+
+// bindConverter converts a value of the registered type into a value that is passed to the database
+type bindConverter func(value reflect.Value) (interface{}, error)
+
+// WithTypeConverter registers a function that converts the values scanned from the database into T,
+// so that T doesn't have to implement sql.Scanner.
+// The function is also used for the fields of type *T, which are left nil when the column is NULL,
+// otherwise it receives nil for NULL.
+func WithTypeConverter[T any](scan func(src interface{}) (T, error)) APIOption {
+	return func(api *API) {
+		if api.scanConverters == nil {
+			api.scanConverters = map[reflect.Type]scanConverter{}
+		}
+		api.scanConverters[reflect.TypeOf((*T)(nil)).Elem()] = func(src interface{}) (reflect.Value, error) {
+			value, err := scan(src)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(&value).Elem(), nil
+		}
+	}
+}
+
+// WithBindConverter registers a function that converts the values of T bound to the named params
+// into values the driver accepts, so that T doesn't have to implement driver.Valuer.
+// The function is also used for the values of type *T, nil pointers are bound as NULL.
+func WithBindConverter[T any](bind func(T) (interface{}, error)) APIOption {
+	return func(api *API) {
+		if api.bindConverters == nil {
+			api.bindConverters = map[reflect.Type]bindConverter{}
+		}
+		api.bindConverters[reflect.TypeOf((*T)(nil)).Elem()] = func(value reflect.Value) (interface{}, error) {
+			return bind(value.Interface().(T))
+		}
+	}
+}
+
+// scanConverterOf returns the converter registered for the type, or for the element of the pointer type
+func (api *API) scanConverterOf(t reflect.Type) (conv scanConverter, byPtr bool) {
+	if conv, ok := api.scanConverters[t]; ok {
+		return conv, false
+	}
+	if t.Kind() == reflect.Ptr {
+		if conv, ok := api.scanConverters[t.Elem()]; ok {
+			return conv, true
+		}
+	}
+	return nil, false
+}
+
+// convertScanned converts the value scanned for a column into the type of the field
+func (api *API) convertScanned(fieldType reflect.Type, src interface{}) (reflect.Value, error) {
+	conv, byPtr := api.scanConverterOf(fieldType)
+	if byPtr && src == nil {
+		return reflect.Zero(fieldType), nil
+	}
+	value, err := conv(src)
+	if err != nil {
+		return reflect.Value{}, errors.Wrapf(err, "scany: convert %T into %v", src, fieldType)
+	}
+	if byPtr {
+		ptr := reflect.New(fieldType.Elem())
+		ptr.Elem().Set(value)
+		return ptr, nil
+	}
+	return value, nil
+}
+
+// BindValue converts the value with the bind converter registered for its type,
+// values of other types are returned as they are.
+func (api *API) BindValue(value interface{}) (interface{}, error) {
+	return api.bindValue(value)
+}
+
+// bindValue converts the value bound to a named param with the registered converter of its type
+func (api *API) bindValue(value interface{}) (interface{}, error) {
+	if len(api.bindConverters) == 0 || value == nil {
+		return value, nil
+	}
+	val := reflect.ValueOf(value)
+	if conv, ok := api.bindConverters[val.Type()]; ok {
+		return conv(val)
+	}
+	if val.Kind() == reflect.Ptr {
+		if conv, ok := api.bindConverters[val.Type().Elem()]; ok {
+			if val.IsNil() {
+				return nil, nil
+			}
+			return conv(val.Elem())
+		}
+	}
+	return value, nil
+}
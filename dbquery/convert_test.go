@@ -0,0 +1,113 @@
+package dbquery
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testMoney struct {
+	Cents int64
+}
+
+func newConverterAPI(t *testing.T) *API {
+	api, err := NewAPI(
+		WithLexer(':', SequentialDollarDelim),
+		WithTypeConverter(func(src interface{}) (testMoney, error) {
+			switch v := src.(type) {
+			case int64:
+				return testMoney{Cents: v}, nil
+			case nil:
+				return testMoney{}, nil
+			}
+			return testMoney{}, fmt.Errorf("unsupported money value %T", src)
+		}),
+		WithTypeConverter(func(src interface{}) (time.Duration, error) {
+			return time.Duration(src.(int64)) * time.Millisecond, nil
+		}),
+		WithBindConverter(func(m testMoney) (interface{}, error) {
+			return m.Cents, nil
+		}),
+	)
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+	return api
+}
+
+func TestScanTypeConverter(t *testing.T) {
+	api := newConverterAPI(t)
+
+	type Product struct {
+		Name     string
+		Price    testMoney
+		Discount *testMoney
+		Warranty time.Duration
+	}
+
+	rows := newFakeRows(
+		[]string{"name", "price", "discount", "warranty"},
+		[]interface{}{"hat", int64(1500), int64(200), int64(60000)},
+		[]interface{}{"scarf", int64(900), nil, int64(0)},
+	)
+
+	var got []Product
+	if err := api.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := []Product{
+		{Name: "hat", Price: testMoney{Cents: 1500}, Discount: &testMoney{Cents: 200}, Warranty: time.Minute},
+		{Name: "scarf", Price: testMoney{Cents: 900}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+
+	rows = newFakeRows([]string{"price"}, []interface{}{int64(1)}, []interface{}{int64(2)})
+	var prices []testMoney
+	if err := api.ScanAll(&prices, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+	if !reflect.DeepEqual(prices, []testMoney{{Cents: 1}, {Cents: 2}}) {
+		t.Errorf("Expected prices to be [{1} {2}], but got: %v", prices)
+	}
+
+	rows = newFakeRows([]string{"name", "price", "discount", "warranty"}, []interface{}{"hat", "free", nil, int64(0)})
+	var product Product
+	if err := api.ScanOne(&product, rows); err == nil {
+		t.Error("Expected the converter error to fail the scan")
+	}
+}
+
+func TestBindConverter(t *testing.T) {
+	api := newConverterAPI(t)
+
+	type Update struct {
+		ID    int64
+		Price testMoney
+		Floor *testMoney
+	}
+
+	_, args, err := api.NamedQueryParams(
+		"UPDATE products SET price = GREATEST(:price, :floor) WHERE id = :id",
+		&Update{ID: 1, Price: testMoney{Cents: 1500}},
+	)
+	if err != nil {
+		t.Fatal("Errored while trying to compile query", err)
+	}
+
+	expected := []interface{}{int64(1500), nil, int64(1)}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected args to be %v, but got: %v", expected, args)
+	}
+
+	_, args, err = api.NamedQueryParams("SELECT :price", map[string]interface{}{"price": testMoney{Cents: 5}})
+	if err != nil {
+		t.Fatal("Errored while trying to compile query", err)
+	}
+	if !reflect.DeepEqual(args, []interface{}{int64(5)}) {
+		t.Errorf("Expected args to be [5], but got: %v", args)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 )
@@ -15,7 +16,16 @@ type API struct {
 	scannableTypesOption  []interface{}
 	scannableTypesReflect []reflect.Type
 	allowUnknownColumns   bool
+	strictScan            bool
+	nullZero              bool
+	scanConverters        map[reflect.Type]scanConverter
+	bindConverters        map[reflect.Type]bindConverter
+	duplicateKeys         DuplicateKeyPolicy
+	structPlans           sync.Map
+	primaryKeys           map[reflect.Type][]string
 	lexer                 Lexer
+	identQuoter           IdentQuoter
+	allowedIdents         map[string]struct{}
 }
 
 // Rows is an abstract database rows that dbscan can iterate over and get the data from.
@@ -28,6 +38,15 @@ type Rows interface {
 	Scan(dest ...interface{}) error
 }
 
+// QualifiedRows is implemented by the Rows that qualify the names of duplicate columns with their tables,
+// like users.id and orders.id. A qualified column maps to the field of its plain name
+// when no other column maps to that field, other dotted columns only map to nested struct fields.
+type QualifiedRows interface {
+	Rows
+	// UnqualifiedColumns returns the plain names of the columns in their order.
+	UnqualifiedColumns() ([]string, error)
+}
+
 type APIOption func(api *API)
 
 func NewAPI(opts ...APIOption) (*API, error) {
@@ -35,6 +54,7 @@ func NewAPI(opts ...APIOption) (*API, error) {
 		fieldMapperFn:   SnakeCaseMapper,
 		columnSeparator: ".",
 		structTagKey:    "db",
+		identQuoter:     DoubleQuoteIdent,
 	}
 
 	for _, o := range opts {
@@ -44,8 +64,18 @@ func NewAPI(opts ...APIOption) (*API, error) {
 	return api, nil
 }
 
+// ColumnSeparator returns the separator of the column name parts that map to nested structs
+func (api *API) ColumnSeparator() string {
+	return api.columnSeparator
+}
+
 func (api *API) NamedQueryParams(query string, arg interface{}) (string, []interface{}, error) {
-	compiledQuery, argNames, err := api.lexer.Compile(query)
+	compiledQuery, argNames, idents, err := api.lexer.compile(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	compiledQuery, err = api.renderIdents(idents, arg)
 	if err != nil {
 		return "", nil, err
 	}
@@ -81,6 +111,24 @@ func WithStructTagKey(structTagKey string) APIOption {
 	}
 }
 
+// WithStrictScan makes scanning into structs fail when the struct has fields
+// that have no corresponding column in the rows, instead of leaving them unset.
+// See ValidateDestination for details.
+func WithStrictScan() APIOption {
+	return func(api *API) {
+		api.strictScan = true
+	}
+}
+
+// WithNullZero makes scanning into struct fields set the zero value of the field when the column is NULL,
+// instead of failing the scan for fields that can't hold NULL, like a string or an int.
+// Individual fields can opt in with the nullzero tag option, e.g. `db:"nick,nullzero"`.
+func WithNullZero() APIOption {
+	return func(api *API) {
+		api.nullZero = true
+	}
+}
+
 // WithLexer allows to set a custom
 func WithLexer(delim rune, compileDelim DriverDelim) APIOption {
 	return func(api *API) {
@@ -88,6 +136,28 @@ func WithLexer(delim rune, compileDelim DriverDelim) APIOption {
 	}
 }
 
+// WithIdentQuoter allows to set a custom function for quoting {{identifier}} values.
+// The default quoter is DoubleQuoteIdent.
+func WithIdentQuoter(quoter IdentQuoter) APIOption {
+	return func(api *API) {
+		api.identQuoter = quoter
+	}
+}
+
+// WithAllowedIdents restricts {{identifier}} values to the given allow-list.
+// Allowed identifiers are quoted like any other, a dot separated part at a time so that
+// qualified names such as "tenant.users" can be allowed, and any other value is rejected.
+func WithAllowedIdents(idents ...string) APIOption {
+	return func(api *API) {
+		if api.allowedIdents == nil {
+			api.allowedIdents = make(map[string]struct{}, len(idents))
+		}
+		for _, ident := range idents {
+			api.allowedIdents[ident] = struct{}{}
+		}
+	}
+}
+
 func mustNewAPI(opts ...APIOption) *API {
 	api, err := NewAPI(opts...)
 	if err != nil {
@@ -116,6 +186,42 @@ func mustNewAPI(opts ...APIOption) *API {
 //
 // Before starting, ScanAll resets the destination slice,
 // so if it's not empty it will overwrite all existing elements.
+//
+// When the struct declares a primary key, by tagging fields with the pk option or with WithPrimaryKey,
+// and has fields that are slices of structs, the rows are aggregated:
+// rows with the same primary key are merged into a single element
+// and the columns prefixed with the column of a slice field are appended into that slice,
+// recursively for the nested slices, for example:
+//
+//	type Order struct {
+//	    ID    int64 `db:"id,pk"`
+//	    Total int64
+//	}
+//
+//	type User struct {
+//	    ID     int64 `db:"id,pk"`
+//	    Name   string
+//	    Orders []Order
+//	}
+//
+//	// SELECT u.id, u.name, o.id AS "orders.id", o.total AS "orders.total"
+//	// FROM users u LEFT JOIN orders o ON o.user_id = u.id
+//
+// Nested elements whose columns are all NULL are left out, as produced by outer joins without matches.
+// The element types of the nested slices must declare a primary key too, otherwise scanning returns an error:
+// it tells apart the identical child rows and merges the rows repeated by joining two sibling slices.
+// With WithStrictScan the columns of every nested element are validated against its struct as well.
+// ScanOne aggregates the same way and expects exactly one element as the result.
+//
+// Rows can also be scanned without a struct: into maps by the column names, like []map[string]interface{},
+// into slices of the values in the column order, like [][]interface{},
+// and into tuples of a fixed number of columns, like []Row2[string, int64].
+// RowScanner.Columns returns the column names to pair with the values.
+//
+// The destination can also be a map keyed by the first column, like map[int64]User or map[string]string,
+// the rest of the columns are scanned into the map values the same way as into the slice elements.
+// A map with struct{} values, like map[int64]struct{}, is a set of the values of the single column.
+// A key that appears in more than one row is handled as set by WithDuplicateKeys.
 func (api *API) ScanAll(dst interface{}, rows Rows) error {
 	err := api.processRows(dst, rows, true /* multipleRows */)
 	return errors.WithStack(err)
@@ -131,6 +237,43 @@ func (api *API) ScanOne(dst interface{}, rows Rows) error {
 	return errors.WithStack(err)
 }
 
+// ScanFirst scans the first row into the destination and closes the rows without iterating the rest of them.
+// Use NotFound function to check if there were no rows.
+func (api *API) ScanFirst(dst interface{}, rows Rows) error {
+	defer rows.Close() // nolint: errcheck
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return errors.Wrap(err, "scany: rows final error")
+		}
+		return errors.WithStack(errNotFound)
+	}
+
+	if err := api.ScanRow(dst, rows); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := rows.Close(); err != nil {
+		return errors.Wrap(err, "scany: close rows after processing")
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "scany: rows final error")
+	}
+	return nil
+}
+
+// ScanOptional is like ScanOne, but instead of the not found error
+// it returns false when there were no rows.
+func (api *API) ScanOptional(dst interface{}, rows Rows) (bool, error) {
+	err := api.ScanOne(dst, rows)
+	if NotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, nil
+}
+
 // NotFound returns true if err is a not found error.
 // This error is returned by ScanOne if there were no rows.
 func NotFound(err error) bool {
@@ -148,6 +291,9 @@ type sliceDestinationMeta struct {
 func (api *API) processRows(dst interface{}, rows Rows, multipleRows bool) error {
 	defer rows.Close() // nolint: errcheck
 	var sliceMeta *sliceDestinationMeta
+	if dstVal, err := parseDestination(dst); multipleRows && err == nil && dstVal.Kind() == reflect.Map {
+		return errors.WithStack(api.processKeyedRows(dstVal, rows))
+	}
 	if multipleRows {
 		var err error
 		sliceMeta, err = api.parseSliceDestination(dst)
@@ -156,7 +302,29 @@ func (api *API) processRows(dst interface{}, rows Rows, multipleRows bool) error
 		}
 		// Make sure slice is empty.
 		sliceMeta.val.Set(sliceMeta.val.Slice(0, 0))
+	} else if dstVal, err := parseDestination(dst); err == nil && api.isAggregated(dstVal.Type()) {
+		// The single result may span multiple rows, so they are aggregated
+		// into a temporary slice that must end up with exactly one element.
+		sliceMeta = &sliceDestinationMeta{
+			val:             reflect.New(reflect.SliceOf(dstVal.Type())).Elem(),
+			elementBaseType: dstVal.Type(),
+		}
+		if err := api.processAggregatedRows(sliceMeta, rows); err != nil {
+			return errors.WithStack(err)
+		}
+		if sliceMeta.val.Len() == 0 {
+			return errors.WithStack(errNotFound)
+		} else if sliceMeta.val.Len() > 1 {
+			return errors.Errorf("scany: expected 1 aggregated result, got: %d", sliceMeta.val.Len())
+		}
+		dstVal.Set(sliceMeta.val.Index(0))
+		return nil
+	}
+
+	if multipleRows && api.isAggregated(sliceMeta.elementBaseType) {
+		return errors.WithStack(api.processAggregatedRows(sliceMeta, rows))
 	}
+
 	rs := api.NewRowScanner(rows)
 	var rowsAffected int
 	for rows.Next() {
@@ -191,6 +359,21 @@ func (api *API) processRows(dst interface{}, rows Rows, multipleRows bool) error
 	return nil
 }
 
+func (api *API) processAggregatedRows(sliceMeta *sliceDestinationMeta, rows Rows) error {
+	if err := api.aggregateRows(sliceMeta, rows); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "scany: rows final error")
+	}
+
+	if err := rows.Close(); err != nil {
+		return errors.Wrap(err, "scany: close rows after processing")
+	}
+	return nil
+}
+
 func (api *API) parseSliceDestination(dst interface{}) (*sliceDestinationMeta, error) {
 	dstValue, err := parseDestination(dst)
 	if err != nil {
@@ -230,18 +413,22 @@ func (api *API) parseSliceDestination(dst interface{}) (*sliceDestinationMeta, e
 }
 
 func scanSliceElement(rs *RowScanner, sliceMeta *sliceDestinationMeta) error {
+	if !sliceMeta.elementByPtr {
+		// Scanning straight into the appended element saves allocating it separately.
+		length := sliceMeta.val.Len()
+		sliceMeta.val.Set(reflect.Append(sliceMeta.val, reflect.Zero(sliceMeta.elementBaseType)))
+		if err := rs.doScan(sliceMeta.val.Index(length)); err != nil {
+			sliceMeta.val.Set(sliceMeta.val.Slice(0, length))
+			return errors.WithStack(err)
+		}
+		return nil
+	}
+
 	dstValPtr := reflect.New(sliceMeta.elementBaseType)
 	if err := rs.Scan(dstValPtr.Interface()); err != nil {
 		return errors.WithStack(err)
 	}
-	var elemVal reflect.Value
-	if sliceMeta.elementByPtr {
-		elemVal = dstValPtr
-	} else {
-		elemVal = dstValPtr.Elem()
-	}
-
-	sliceMeta.val.Set(reflect.Append(sliceMeta.val, elemVal))
+	sliceMeta.val.Set(reflect.Append(sliceMeta.val, dstValPtr))
 	return nil
 }
 
@@ -264,6 +451,9 @@ func (api *API) ScanRow(dst interface{}, rows Rows) error {
 }
 
 func (api *API) isScannableType(dstType reflect.Type) bool {
+	if _, ok := api.scanConverters[dstType]; ok {
+		return true
+	}
 	dstRefType := reflect.PtrTo(dstType)
 	for _, st := range api.scannableTypesReflect {
 		if dstRefType.Implements(st) || dstType.Implements(st) {
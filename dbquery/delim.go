@@ -21,4 +21,33 @@ func SequentialDollarDelim(builder *strings.Builder, index uint8) error {
 func QuestionDelim(builder *strings.Builder, index uint8) error {
 	_, err := builder.WriteRune('?')
 	return err
-}
\ No newline at end of file
+}
+
+// IdentQuoter is type of function used for quoting {{identifier}} values in the format of the database dialect
+type IdentQuoter = func(builder *strings.Builder, ident string) error
+
+// DoubleQuoteIdent quotes identifiers the way Postgres and the SQL standard do, e.g. "users"
+func DoubleQuoteIdent(builder *strings.Builder, ident string) error {
+	return quoteIdent(builder, ident, '"')
+}
+
+// BacktickIdent quotes identifiers the way MySQL does, e.g. `users`
+func BacktickIdent(builder *strings.Builder, ident string) error {
+	return quoteIdent(builder, ident, '`')
+}
+
+func quoteIdent(builder *strings.Builder, ident string, quote rune) error {
+	_, err := builder.WriteRune(quote)
+	if err != nil {
+		return err
+	}
+
+	// The quote character is escaped by doubling it.
+	_, err = builder.WriteString(strings.ReplaceAll(ident, string(quote), string(quote)+string(quote)))
+	if err != nil {
+		return err
+	}
+
+	_, err = builder.WriteRune(quote)
+	return err
+}
@@ -0,0 +1,121 @@
+package dbquery
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	identOpen  = "{{"
+	identClose = "}}"
+)
+
+// identTemplate is a query split around its {{identifier}} slots, which the lexer finds outside of
+// the string literals, quoted identifiers and comments, so that len(parts) is always len(names)+1.
+// Identifiers are never passed to the database as parameters,
+// instead they are validated or quoted and spliced into the query text.
+type identTemplate struct {
+	parts []string
+	names []string
+}
+
+// parseIdentSlot parses the {{identifier}} slot at the start of the query,
+// returning the identifier name and the length of the slot
+func parseIdentSlot(query string) (string, int, error) {
+	end := strings.Index(query[len(identOpen):], identClose)
+	if end < 0 {
+		return "", 0, errors.Errorf("orava ident: unterminated identifier at '%s'", query)
+	}
+	end += len(identOpen)
+
+	name := strings.TrimSpace(query[len(identOpen):end])
+	if !isValidIdentName(name) {
+		return "", 0, errors.Errorf("orava ident: invalid identifier name '%s'", name)
+	}
+
+	return name, end + len(identClose), nil
+}
+
+func isValidIdentName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		if r != '_' && r != '.' && (r < '0' || r > '9') && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (t *identTemplate) hasIdents() bool {
+	return len(t.names) > 0
+}
+
+// renderIdents looks up the identifier values from the arg and splices them into the query
+func (api *API) renderIdents(t *identTemplate, arg interface{}) (string, error) {
+	if !t.hasIdents() {
+		return t.parts[0], nil
+	}
+
+	if arg == nil {
+		return "", errors.New("orava ident: identifiers require a non nil argument")
+	}
+
+	values, err := api.args(arg, t.names)
+	if err != nil {
+		return "", errors.Wrap(err, "orava ident")
+	}
+
+	sb := &strings.Builder{}
+	for i, name := range t.names {
+		sb.WriteString(t.parts[i])
+
+		if err := api.writeIdent(sb, name, values[i]); err != nil {
+			return "", err
+		}
+	}
+	sb.WriteString(t.parts[len(t.parts)-1])
+
+	return sb.String(), nil
+}
+
+func (api *API) writeIdent(sb *strings.Builder, name string, value interface{}) error {
+	val := reflect.ValueOf(value)
+	if !val.IsValid() || val.Kind() != reflect.String {
+		return errors.Errorf("orava ident: value of '%s' must be a string, got: %T", name, value)
+	}
+
+	ident := val.String()
+	if ident == "" {
+		return errors.Errorf("orava ident: value of '%s' is empty", name)
+	}
+
+	if strings.ContainsRune(ident, 0) {
+		return errors.Errorf("orava ident: value of '%s' contains a NUL character", name)
+	}
+
+	if len(api.allowedIdents) > 0 {
+		if _, ok := api.allowedIdents[ident]; !ok {
+			return errors.Errorf("orava ident: value '%s' of '%s' is not allowed", ident, name)
+		}
+
+		// Allow-listed identifiers are written by the developer, so qualified names such as "tenant.users"
+		// are quoted a part at a time.
+		for i, part := range strings.Split(ident, ".") {
+			if i > 0 {
+				sb.WriteByte('.')
+			}
+			if err := api.identQuoter(sb, part); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return api.identQuoter(sb, ident)
+}
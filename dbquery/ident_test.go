@@ -0,0 +1,169 @@
+package dbquery
+
+import (
+	"testing"
+)
+
+func TestIdentQuoted(t *testing.T) {
+	api, err := NewAPI(WithLexer(':', SequentialDollarDelim))
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	type Listing struct {
+		Table string
+		Sort  string
+		Name  string
+	}
+
+	query, args, err := api.NamedQueryParams(
+		"SELECT * FROM {{table}} WHERE name = :name ORDER BY {{ sort }}",
+		&Listing{Table: `us"ers`, Sort: "created_at", Name: "bob"},
+	)
+	if err != nil {
+		t.Fatal("Errored while trying to compile query", err)
+	}
+
+	expected := `SELECT * FROM "us""ers" WHERE name = $1 ORDER BY "created_at"`
+	if query != expected {
+		t.Error("Expected: '" + expected + "', but got: '" + query + "'")
+	}
+
+	if len(args) != 1 || args[0].(string) != "bob" {
+		t.Errorf("Expected args to be [bob], but got: %v", args)
+	}
+}
+
+func TestIdentAllowList(t *testing.T) {
+	api, err := NewAPI(
+		WithLexer(':', SequentialDollarDelim),
+		WithAllowedIdents("tenant_a.users", "tenant_b.users"),
+	)
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	query, _, err := api.NamedQueryParams("SELECT * FROM {{table}}", map[string]string{"table": "tenant_a.users"})
+	if err != nil {
+		t.Fatal("Errored while trying to compile query", err)
+	}
+
+	expected := `SELECT * FROM "tenant_a"."users"`
+	if query != expected {
+		t.Error("Expected: '" + expected + "', but got: '" + query + "'")
+	}
+
+	_, _, err = api.NamedQueryParams("SELECT * FROM {{table}}", map[string]string{"table": "users; DROP TABLE users"})
+	if err == nil {
+		t.Fatal("Expected identifier outside of the allow-list to be rejected")
+	}
+}
+
+func TestIdentBacktick(t *testing.T) {
+	api, err := NewAPI(WithLexer(':', QuestionDelim), WithIdentQuoter(BacktickIdent))
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	query, _, err := api.NamedQueryParams("SELECT * FROM {{table}} WHERE id = :id", map[string]interface{}{"table": "us`ers", "id": 1})
+	if err != nil {
+		t.Fatal("Errored while trying to compile query", err)
+	}
+
+	expected := "SELECT * FROM `us``ers` WHERE id = ?"
+	if query != expected {
+		t.Error("Expected: '" + expected + "', but got: '" + query + "'")
+	}
+}
+
+func TestIdentErrors(t *testing.T) {
+	api, err := NewAPI(WithLexer(':', SequentialDollarDelim))
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	testCases := []struct {
+		input string
+		arg   interface{}
+	}{
+		{input: "SELECT * FROM {{table", arg: map[string]string{"table": "users"}},
+		{input: "SELECT * FROM {{ta-ble}}", arg: map[string]string{"ta-ble": "users"}},
+		{input: "SELECT * FROM {{table}}", arg: map[string]string{"table": ""}},
+		{input: "SELECT * FROM {{table}}", arg: map[string]int{"table": 1}},
+		{input: "SELECT * FROM {{table}}", arg: nil},
+	}
+
+	for _, testCase := range testCases {
+		_, _, err := api.NamedQueryParams(testCase.input, testCase.arg)
+		if err == nil {
+			t.Error("Expected an error for query: '" + testCase.input + "'")
+		}
+	}
+}
+
+func TestIdentPrepared(t *testing.T) {
+	api, err := NewAPI(WithLexer(':', SequentialDollarDelim))
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	type Search struct {
+		Schema string
+		Model  string
+	}
+
+	pq, err := api.PrepareNamed("SELECT * FROM {{schema}}.cars WHERE model = :model", Search{})
+	if err != nil {
+		t.Fatal("Errored while trying to prepare query", err)
+	}
+
+	query, args, err := pq.GetQuery(&Search{Schema: "tenant_a", Model: "Saab"})
+	if err != nil {
+		t.Fatal("Errored while trying to get query", err)
+	}
+
+	expected := `SELECT * FROM "tenant_a".cars WHERE model = $1`
+	if query != expected {
+		t.Error("Expected: '" + expected + "', but got: '" + query + "'")
+	}
+
+	if len(args) != 1 || args[0].(string) != "Saab" {
+		t.Errorf("Expected args to be [Saab], but got: %v", args)
+	}
+
+	type Car struct {
+		Model string
+	}
+
+	_, err = api.PrepareNamed("SELECT * FROM {{schema}}.cars WHERE model = :model", Car{})
+	expectedErr := "field 'schema' was not found from 'Car' struct."
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected error: '%s', but got: '%v'", expectedErr, err)
+	}
+}
+
+func TestIdentSkipsLiteralsAndComments(t *testing.T) {
+	api, err := NewAPI(WithLexer(':', SequentialDollarDelim))
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	query, args, err := api.NamedQueryParams(
+		"SELECT '{{table}} :name', \"{{col}}\", $$ {{x}} $$ FROM {{table}} -- {{comment\n"+
+			"WHERE note = E'it\\'s {{y}}' /* :name */ AND name = :name",
+		map[string]string{"table": "users", "name": "bob"},
+	)
+	if err != nil {
+		t.Fatal("Errored while trying to compile query", err)
+	}
+
+	expected := "SELECT '{{table}} :name', \"{{col}}\", $$ {{x}} $$ FROM \"users\" -- {{comment\n" +
+		"WHERE note = E'it\\'s {{y}}' /* :name */ AND name = $1"
+	if query != expected {
+		t.Error("Expected: '" + expected + "', but got: '" + query + "'")
+	}
+
+	if len(args) != 1 || args[0].(string) != "bob" {
+		t.Errorf("Expected args to be [bob], but got: %v", args)
+	}
+}
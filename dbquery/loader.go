@@ -0,0 +1,261 @@
+package dbquery
+
+import (
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// QueryKind describes how the result of a loaded query is meant to be consumed.
+type QueryKind string
+
+const (
+	// QueryOne is a query that returns exactly one row.
+	QueryOne QueryKind = ":one"
+	// QueryMany is a query that returns any number of rows.
+	QueryMany QueryKind = ":many"
+	// QueryExec is a query that returns no rows.
+	QueryExec QueryKind = ":exec"
+)
+
+// QueryDef is a single named query block parsed from a .sql file, for example:
+//
+//	-- name: GetUserByID :one
+//	-- params: UserParams
+//	SELECT * FROM users WHERE id = :id;
+//
+// Comment lines in the "-- key: value" format directly after the name line
+// are collected into Meta.
+type QueryDef struct {
+	Name string
+	Kind QueryKind
+	Meta map[string]string
+	SQL  string
+	File string
+	Line int
+}
+
+var (
+	queryNameRe  = regexp.MustCompile(`^--\s*name:\s*(.*)$`)
+	queryMetaRe  = regexp.MustCompile(`^--\s*([A-Za-z_][A-Za-z0-9_]*):\s*(.*)$`)
+	queryIdentRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// ParseQueries parses the named query blocks from the contents of a .sql file.
+// The file name is only used in the error messages.
+func ParseQueries(file string, src string) ([]QueryDef, error) {
+	var defs []QueryDef
+	var current *QueryDef
+	var body []string
+	inHeader := false
+
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		current.SQL = strings.TrimSuffix(strings.TrimSpace(strings.Join(body, "\n")), ";")
+		current.SQL = strings.TrimSpace(current.SQL)
+		if current.SQL == "" {
+			return errors.Errorf("scany: %s:%d: query '%s' has no body", file, current.Line, current.Name)
+		}
+		defs = append(defs, *current)
+		return nil
+	}
+
+	for i, line := range strings.Split(src, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if match := queryNameRe.FindStringSubmatch(trimmed); match != nil {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+
+			def, err := parseQueryHeader(file, i+1, match[1])
+			if err != nil {
+				return nil, err
+			}
+			current = def
+			body = body[:0]
+			inHeader = true
+			continue
+		}
+
+		if inHeader {
+			if match := queryMetaRe.FindStringSubmatch(trimmed); match != nil {
+				current.Meta[match[1]] = strings.TrimSpace(match[2])
+				continue
+			}
+			inHeader = false
+		}
+
+		if current == nil {
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return nil, errors.Errorf("scany: %s:%d: statement outside of a named query block", file, i+1)
+			}
+			continue
+		}
+
+		body = append(body, line)
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+func parseQueryHeader(file string, line int, header string) (*QueryDef, error) {
+	fields := strings.Fields(header)
+	if len(fields) != 2 {
+		return nil, errors.Errorf("scany: %s:%d: expected '-- name: <Name> <:kind>', got '-- name: %s'", file, line, header)
+	}
+
+	name, kind := fields[0], QueryKind(fields[1])
+	if !queryIdentRe.MatchString(name) {
+		return nil, errors.Errorf("scany: %s:%d: invalid query name '%s'", file, line, name)
+	}
+
+	switch kind {
+	case QueryOne, QueryMany, QueryExec:
+	default:
+		return nil, errors.Errorf("scany: %s:%d: unknown query kind '%s' of '%s'", file, line, kind, name)
+	}
+
+	return &QueryDef{
+		Name: name,
+		Kind: kind,
+		Meta: map[string]string{},
+		File: file,
+		Line: line,
+	}, nil
+}
+
+// QueryRegistry holds the queries loaded with LoadQueries by their names.
+type QueryRegistry struct {
+	defs    map[string]QueryDef
+	queries map[string]*PreparedQuery
+}
+
+// LoadQueries parses all of the .sql files matching the pattern in the file system,
+// the file system can be an embed.FS or os.DirFS for example.
+// Each query is prepared with PrepareNamed and asserted against the example structs
+// given for its name in examples, which may be nil.
+// Every malformed or unassertable query is reported in the returned error.
+func (api *API) LoadQueries(fsys fs.FS, pattern string, examples map[string][]interface{}) (*QueryRegistry, error) {
+	files, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, errors.Wrap(err, "scany: load queries")
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("scany: no files match '%s'", pattern)
+	}
+
+	registry := &QueryRegistry{
+		defs:    map[string]QueryDef{},
+		queries: map[string]*PreparedQuery{},
+	}
+	var defs []QueryDef
+	var errs []string
+
+	for _, file := range files {
+		src, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, errors.Wrap(err, "scany: load queries")
+		}
+
+		fileDefs, err := ParseQueries(file, string(src))
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		defs = append(defs, fileDefs...)
+	}
+
+	errs = append(errs, duplicateQueries(defs)...)
+
+	for _, def := range defs {
+		if _, found := registry.defs[def.Name]; found {
+			continue
+		}
+		registry.defs[def.Name] = def
+
+		prep, err := api.PrepareNamed(def.SQL, examples[def.Name]...)
+		if err != nil {
+			errs = append(errs, "scany: "+def.File+": query '"+def.Name+"': "+err.Error())
+			continue
+		}
+		prep.name = def.Name
+		registry.queries[def.Name] = prep
+	}
+
+	for name := range examples {
+		if _, found := registry.defs[name]; !found {
+			errs = append(errs, "scany: examples given for unknown query '"+name+"'")
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, errors.New(strings.Join(errs, "\n"))
+	}
+
+	return registry, nil
+}
+
+// CheckDuplicateQueries returns an error naming the files of every query
+// that has the same name as an earlier query of defs.
+func CheckDuplicateQueries(defs []QueryDef) error {
+	if errs := duplicateQueries(defs); len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+func duplicateQueries(defs []QueryDef) []string {
+	var errs []string
+	seen := make(map[string]QueryDef, len(defs))
+	for _, def := range defs {
+		if prev, found := seen[def.Name]; found {
+			errs = append(errs, "scany: "+def.File+": query '"+def.Name+"' is already defined in "+prev.File)
+			continue
+		}
+		seen[def.Name] = def
+	}
+	return errs
+}
+
+// Get returns the prepared query with the name
+func (r *QueryRegistry) Get(name string) (*PreparedQuery, bool) {
+	prep, found := r.queries[name]
+	return prep, found
+}
+
+// MustGet returns the prepared query with the name and panics if it does not exist
+func (r *QueryRegistry) MustGet(name string) *PreparedQuery {
+	prep, found := r.queries[name]
+	if !found {
+		panic("scany: query '" + name + "' not found")
+	}
+	return prep
+}
+
+// Def returns the parsed definition of the query with the name
+func (r *QueryRegistry) Def(name string) (QueryDef, bool) {
+	def, found := r.defs[name]
+	return def, found
+}
+
+// Names returns the names of all loaded queries in alphabetical order
+func (r *QueryRegistry) Names() []string {
+	names := make([]string, 0, len(r.defs))
+	for name := range r.defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
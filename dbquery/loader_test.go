@@ -0,0 +1,192 @@
+package dbquery
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const usersSQL = `-- Queries for the users table.
+
+-- name: GetUserByID :one
+-- params: UserParams
+-- result: User
+SELECT id, name FROM users WHERE id = :id;
+
+-- name: ListUsers :many
+SELECT id, name
+FROM users
+ORDER BY name;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = :id
+`
+
+func TestParseQueries(t *testing.T) {
+	defs, err := ParseQueries("users.sql", usersSQL)
+	if err != nil {
+		t.Fatal("Errored while trying to parse queries", err)
+	}
+
+	if len(defs) != 3 {
+		t.Fatalf("Expected 3 queries, but got: %d", len(defs))
+	}
+
+	expected := []QueryDef{
+		{Name: "GetUserByID", Kind: QueryOne, SQL: "SELECT id, name FROM users WHERE id = :id", Line: 3},
+		{Name: "ListUsers", Kind: QueryMany, SQL: "SELECT id, name\nFROM users\nORDER BY name", Line: 8},
+		{Name: "DeleteUser", Kind: QueryExec, SQL: "DELETE FROM users WHERE id = :id", Line: 13},
+	}
+
+	for i, def := range defs {
+		if def.Name != expected[i].Name || def.Kind != expected[i].Kind || def.SQL != expected[i].SQL || def.Line != expected[i].Line {
+			t.Errorf("Expected: %+v, but got: %+v", expected[i], def)
+		}
+	}
+
+	if defs[0].Meta["params"] != "UserParams" || defs[0].Meta["result"] != "User" {
+		t.Errorf("Expected params and result annotations, but got: %v", defs[0].Meta)
+	}
+}
+
+func TestParseQueriesCRLF(t *testing.T) {
+	defs, err := ParseQueries("users.sql", strings.ReplaceAll(usersSQL, "\n", "\r\n"))
+	if err != nil {
+		t.Fatal("Errored while trying to parse queries", err)
+	}
+
+	if len(defs) != 3 {
+		t.Fatalf("Expected 3 queries, but got: %d", len(defs))
+	}
+
+	expected := "SELECT id, name\nFROM users\nORDER BY name"
+	if defs[1].SQL != expected {
+		t.Errorf("Expected: %q, but got: %q", expected, defs[1].SQL)
+	}
+	if defs[0].Meta["result"] != "User" {
+		t.Errorf("Expected: %q, but got: %q", "User", defs[0].Meta["result"])
+	}
+}
+
+func TestParseQueriesErrors(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedErrStr string
+	}{
+		{
+			input:          "SELECT 1;\n-- name: One :one\nSELECT 1",
+			expectedErrStr: "scany: q.sql:1: statement outside of a named query block",
+		},
+		{
+			input:          "-- name: One\nSELECT 1",
+			expectedErrStr: "scany: q.sql:1: expected '-- name: <Name> <:kind>', got '-- name: One'",
+		},
+		{
+			input:          "-- name: One :first\nSELECT 1",
+			expectedErrStr: "scany: q.sql:1: unknown query kind ':first' of 'One'",
+		},
+		{
+			input:          "-- name: One-Two :one\nSELECT 1",
+			expectedErrStr: "scany: q.sql:1: invalid query name 'One-Two'",
+		},
+		{
+			input:          "-- name: One :one\n\n-- name: Two :one\nSELECT 2",
+			expectedErrStr: "scany: q.sql:1: query 'One' has no body",
+		},
+	}
+
+	for _, testCase := range testCases {
+		_, err := ParseQueries("q.sql", testCase.input)
+		if err == nil || err.Error() != testCase.expectedErrStr {
+			t.Errorf("Expected error: '%s', but got: '%v'", testCase.expectedErrStr, err)
+		}
+	}
+}
+
+func TestLoadQueries(t *testing.T) {
+	api, err := NewAPI(WithLexer(':', SequentialDollarDelim))
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	type UserParams struct {
+		ID int64
+	}
+
+	fsys := fstest.MapFS{
+		"queries/users.sql": &fstest.MapFile{Data: []byte(usersSQL)},
+	}
+
+	registry, err := api.LoadQueries(fsys, "queries/*.sql", map[string][]interface{}{
+		"GetUserByID": {UserParams{}},
+		"DeleteUser":  {UserParams{}},
+	})
+	if err != nil {
+		t.Fatal("Errored while trying to load queries", err)
+	}
+
+	names := strings.Join(registry.Names(), ",")
+	if names != "DeleteUser,GetUserByID,ListUsers" {
+		t.Error("Expected all queries to be loaded, but got: " + names)
+	}
+
+	pq := registry.MustGet("GetUserByID")
+	if pq.Name() != "GetUserByID" {
+		t.Error("Expected name 'GetUserByID', but got: '" + pq.Name() + "'")
+	}
+
+	expectedQuery := "SELECT id, name FROM users WHERE id = $1"
+	if pq.Query() != expectedQuery {
+		t.Error("Expected: '" + expectedQuery + "', but got: '" + pq.Query() + "'")
+	}
+}
+
+func TestLoadQueriesErrors(t *testing.T) {
+	api, err := NewAPI(WithLexer(':', SequentialDollarDelim))
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	type Car struct {
+		Model string
+	}
+
+	fsys := fstest.MapFS{
+		"a.sql": &fstest.MapFile{Data: []byte("-- name: GetCar :one\nSELECT * FROM cars WHERE model = :modeel")},
+		"b.sql": &fstest.MapFile{Data: []byte("-- name: GetCar :one\nSELECT * FROM cars")},
+	}
+
+	_, err = api.LoadQueries(fsys, "*.sql", map[string][]interface{}{
+		"GetCar":  {Car{}},
+		"Missing": {Car{}},
+	})
+
+	expectedErr := "scany: a.sql: query 'GetCar': field 'modeel' was not found from 'Car' struct.\n" +
+		"scany: b.sql: query 'GetCar' is already defined in a.sql\n" +
+		"scany: examples given for unknown query 'Missing'"
+	if err == nil {
+		t.Fatal("Expected loading to fail")
+	}
+
+	if err.Error() != expectedErr {
+		t.Error("Expected error: '" + expectedErr + "', but got: '" + err.Error() + "'")
+	}
+}
+
+func TestCheckDuplicateQueries(t *testing.T) {
+	defs := []QueryDef{
+		{Name: "GetCar", File: "a.sql"},
+		{Name: "ListCars", File: "a.sql"},
+		{Name: "GetCar", File: "b.sql"},
+	}
+
+	err := CheckDuplicateQueries(defs)
+	expectedErr := "scany: b.sql: query 'GetCar' is already defined in a.sql"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected error: '%s', but got: '%v'", expectedErr, err)
+	}
+
+	if err := CheckDuplicateQueries(defs[:2]); err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+}
@@ -26,6 +26,8 @@ type builder struct {
 	currentIndex uint8
 	indecies     map[string]uint8
 	argNames     []string
+	idents       *identTemplate
+	identEnd     int
 }
 
 func newBuilder() builder {
@@ -35,6 +37,7 @@ func newBuilder() builder {
 		currentIndex: 0,
 		indecies:     map[string]uint8{},
 		argNames:     []string{},
+		idents:       &identTemplate{},
 	}
 }
 
@@ -64,6 +67,16 @@ func (b *builder) appendPart(str string, compileDelim DriverDelim) error {
 	return nil
 }
 
+// appendIdent writes the {{identifier}} slot as is and splits the ident template around it
+func (b *builder) appendIdent(slot string, name string) {
+	start := b.byteBuf.Len()
+	b.byteBuf.WriteString(slot)
+
+	b.idents.parts = append(b.idents.parts, b.byteBuf.String()[b.identEnd:start])
+	b.idents.names = append(b.idents.names, name)
+	b.identEnd = b.byteBuf.Len()
+}
+
 func (b builder) String() string {
 	return b.byteBuf.String()
 }
@@ -73,8 +86,16 @@ func wrapNamedError(err error) error {
 }
 
 // Compile is used for parsing and compiling sql queries
-// returns the compiled query as the first parameter and the second parameter consists of all of the
+// returns the compiled query as the first parameter and the second parameter consists of all of the named params.
+// String literals, quoted identifiers and comments are copied as they are, so they can contain the delimiter.
+// The {{identifier}} slots are left in the compiled query.
 func (l Lexer) Compile(sql string) (string, []string, error) {
+	query, params, _, err := l.compile(sql)
+	return query, params, err
+}
+
+// compile compiles the query like Compile and also splits the compiled query around its {{identifier}} slots
+func (l Lexer) compile(sql string) (string, []string, *identTemplate, error) {
 	builder := newBuilder()
 	start := 0
 	pos := 0
@@ -85,40 +106,131 @@ func (l Lexer) Compile(sql string) (string, []string, error) {
 		if _rune == utf8.RuneError {
 			err := builder.appendPart(sql[start:pos], l.compileDelim)
 			if err != nil {
-				return "", nil, wrapNamedError(err)
+				return "", nil, nil, wrapNamedError(err)
 			}
 
 			break
 		} else if _rune == l.delim {
 			err := builder.appendPart(sql[start:pos], l.compileDelim)
 			if err != nil {
-				return "", nil, wrapNamedError(err)
+				return "", nil, nil, wrapNamedError(err)
 			}
 
 			builder.onParameter = true
 			start = pos + width
-		} else if _rune != '_' && _rune != '.' && (_rune > 'z' || _rune < '1') {
-			if builder.onParameter {
+		} else {
+			if builder.onParameter && _rune != '_' && _rune != '.' && (_rune > 'z' || _rune < '1') {
 				err := builder.appendPart(sql[start:pos], l.compileDelim)
 				if err != nil {
-					return "", nil, wrapNamedError(err)
+					return "", nil, nil, wrapNamedError(err)
 				}
 
 				builder.onParameter = false
 				start = pos
 			}
+
+			if !builder.onParameter {
+				if skip := skipQuoted(sql, pos); skip > 0 {
+					pos += skip
+					continue
+				}
+
+				if strings.HasPrefix(sql[pos:], identOpen) {
+					name, slotWidth, err := parseIdentSlot(sql[pos:])
+					if err != nil {
+						return "", nil, nil, err
+					}
+
+					err = builder.appendPart(sql[start:pos], l.compileDelim)
+					if err != nil {
+						return "", nil, nil, wrapNamedError(err)
+					}
+
+					builder.appendIdent(sql[pos:pos+slotWidth], name)
+					pos += slotWidth
+					start = pos
+					continue
+				}
+			}
 		}
 
 		pos += width
 	}
 
-	return builder.String(), builder.argNames, nil
+	query := builder.String()
+	builder.idents.parts = append(builder.idents.parts, query[builder.identEnd:])
+	return query, builder.argNames, builder.idents, nil
+}
+
+// skipQuoted returns the length of the string literal, quoted identifier or comment starting at pos,
+// or 0 when there is none
+func skipQuoted(sql string, pos int) int {
+	rest := sql[pos:]
+
+	switch {
+	case rest[0] == '\'' && pos > 0 && (sql[pos-1] == 'E' || sql[pos-1] == 'e'):
+		// Postgres escape strings, e.g. E'it\'s', escape the quote with a backslash.
+		for i := 1; i < len(rest); i++ {
+			if rest[i] == '\\' {
+				i++
+			} else if rest[i] == '\'' {
+				if i+1 < len(rest) && rest[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+		return len(rest)
+	case rest[0] == '\'' || rest[0] == '"' || rest[0] == '`':
+		// The quote character is escaped by doubling it.
+		for i := 1; i < len(rest); i++ {
+			if rest[i] == rest[0] {
+				if i+1 < len(rest) && rest[i+1] == rest[0] {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+		return len(rest)
+	case strings.HasPrefix(rest, "--"):
+		if end := strings.IndexByte(rest, '\n'); end >= 0 {
+			return end
+		}
+		return len(rest)
+	case strings.HasPrefix(rest, "/*"):
+		if end := strings.Index(rest[2:], "*/"); end >= 0 {
+			return end + 4
+		}
+		return len(rest)
+	case rest[0] == '$':
+		// Postgres dollar quoted strings, e.g. $body$ ... $body$, unlike the $1 placeholders.
+		tagEnd := 1
+		for tagEnd < len(rest) && (rest[tagEnd] == '_' || rest[tagEnd] >= 'a' && rest[tagEnd] <= 'z' ||
+			rest[tagEnd] >= 'A' && rest[tagEnd] <= 'Z' || tagEnd > 1 && rest[tagEnd] >= '0' && rest[tagEnd] <= '9') {
+			tagEnd++
+		}
+		if tagEnd >= len(rest) || rest[tagEnd] != '$' {
+			return 0
+		}
+		tag := rest[:tagEnd+1]
+		if end := strings.Index(rest[len(tag):], tag); end >= 0 {
+			return len(tag) + end + len(tag)
+		}
+		return len(rest)
+	}
+
+	return 0
 }
 
 type PreparedQuery struct {
 	api         *API
+	name        string
+	named       string
 	query       string
 	namedParams []string
+	idents      *identTemplate
 }
 
 // Prepares named queries
@@ -126,15 +238,17 @@ type PreparedQuery struct {
 // that need to be done per query. Without preparation
 // each query would approximately take 2000ns.
 func (api *API) PrepareNamed(query string, args ...interface{}) (*PreparedQuery, error) {
-	compiledQuery, params, err := api.lexer.Compile(query)
+	compiledQuery, params, idents, err := api.lexer.compile(query)
 	if err != nil {
 		return nil, err
 	}
 
 	prep := &PreparedQuery{
 		api:         api,
+		named:       query,
 		query:       compiledQuery,
 		namedParams: params,
+		idents:      idents,
 	}
 
 	errSb := strings.Builder{}
@@ -168,7 +282,11 @@ func (pq *PreparedQuery) assertStruct(assertableStruct interface{}) error {
 	fieldIndexMap := pq.api.getColumnToFieldIndexMapV2(st)
 	sBuilder := strings.Builder{}
 
-	for _, fieldName := range pq.namedParams {
+	fieldNames := make([]string, 0, len(pq.namedParams)+len(pq.idents.names))
+	fieldNames = append(fieldNames, pq.namedParams...)
+	fieldNames = append(fieldNames, pq.idents.names...)
+
+	for _, fieldName := range fieldNames {
 		res := fieldIndexMap.fieldIndexes[fieldName]
 		if len(res) == 0 {
 			_, err := sBuilder.WriteString("field '")
@@ -205,10 +323,41 @@ func (pq *PreparedQuery) assertStruct(assertableStruct interface{}) error {
 	return nil
 }
 
-// GetQuery returns the array of the values behind the named params
+// Name returns the name of the query when it has been loaded with LoadQueries
+func (pq *PreparedQuery) Name() string {
+	return pq.name
+}
+
+// NamedQuery returns the query as it was given, with its named params
+func (pq *PreparedQuery) NamedQuery() string {
+	return pq.named
+}
+
+// Query returns the compiled query, it may still contain {{identifier}} slots
+func (pq *PreparedQuery) Query() string {
+	return pq.query
+}
+
+// NamedParams returns the names of the params in the order of their placeholders
+func (pq *PreparedQuery) NamedParams() []string {
+	return append([]string(nil), pq.namedParams...)
+}
+
+// Idents returns the names of the {{identifier}} slots in the query
+func (pq *PreparedQuery) Idents() []string {
+	return append([]string(nil), pq.idents.names...)
+}
+
+// GetQuery returns the query with its {{identifier}} values spliced in
+// and the array of the values behind the named params
 func (pq *PreparedQuery) GetQuery(arg interface{}) (string, []interface{}, error) {
+	query, err := pq.api.renderIdents(pq.idents, arg)
+	if err != nil {
+		return "", nil, err
+	}
+
 	args, err := pq.api.args(arg, pq.namedParams)
-	return pq.query, args, err
+	return query, args, err
 }
 
 // Maps the named args to corresponding fields in a structs and maps
@@ -236,7 +385,10 @@ func (api *API) args(arg interface{}, namedArgs []string) ([]interface{}, error)
 				if !found {
 					return nil, errors.New("value for key '" + key + "' not found")
 				}
-				ret := val.MapIndex(actualKey.Convert(val.Type().Key())).Interface()
+				ret, err := api.bindValue(val.MapIndex(actualKey.Convert(val.Type().Key())).Interface())
+				if err != nil {
+					return nil, errors.Wrapf(err, "bind value for key '%s'", key)
+				}
 
 				args = append(args, ret)
 			}
@@ -260,6 +412,10 @@ func (api *API) args(arg interface{}, namedArgs []string) ([]interface{}, error)
 				if err != nil {
 					return nil, err
 				}
+				val, err = api.bindValue(val)
+				if err != nil {
+					return nil, errors.Wrapf(err, "bind value of field '%s'", key)
+				}
 				args = append(args, val)
 			}
 
@@ -1,7 +1,9 @@
 package dbquery
 
 import (
+	"database/sql"
 	"reflect"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -27,14 +29,49 @@ type startScannerFunc func(rs *RowScanner, dstValue reflect.Value) error
 //
 // ScanOne and ScanAll both use RowScanner type internally.
 type RowScanner struct {
-	api                *API
-	rows               Rows
-	columns            []string
-	columnToFieldIndex map[string][]int
-	mapElementType     reflect.Type
-	started            bool
-	scanFn             func(dstVal reflect.Value) error
-	start              startScannerFunc
+	api            *API
+	rows           Rows
+	columns        []string
+	unqualified    []string
+	structPlan     *structPlan
+	scans          []interface{}
+	holders        []reflect.Value
+	present        []bool
+	mapElementType reflect.Type
+	started        bool
+	scanFn         func(dstVal reflect.Value) error
+	start          startScannerFunc
+}
+
+// structPlan describes how the columns are scanned into a struct type,
+// it's built once for the type and the columns and shared by the RowScanners of the API.
+type structPlan struct {
+	targets  []structTarget
+	pointers *nestedPointers
+	// hasHolders is set when any of the columns is scanned via a holder.
+	hasHolders bool
+	// fieldIndexes are the field indexes of the targets when none of them is scanned via a holder.
+	fieldIndexes [][]int
+}
+
+type structPlanKey struct {
+	structType reflect.Type
+	columns    string
+}
+
+// structTarget describes the struct field the value of a column is scanned into.
+type structTarget struct {
+	// fieldIndex is nil for unknown columns, which values are discarded.
+	fieldIndex []int
+	fieldType  reflect.Type
+	// viaHolder is set when the column is scanned into a NULL-able holder and set into the field afterwards.
+	viaHolder bool
+	// nullZero is set when NULL is scanned into the zero value of the field.
+	nullZero bool
+	// converted is set when the field type has a registered scan converter.
+	converted bool
+	// groups are the indexes of the nested struct pointers on the path to the field in nestedPointers.
+	groups []int
 }
 
 // NewRowScanner is a package-level helper function that uses the DefaultAPI object.
@@ -64,6 +101,19 @@ func (rs *RowScanner) Scan(dst interface{}) error {
 	return errors.WithStack(err)
 }
 
+// Columns returns the names of the columns of the rows in their order,
+// so that the values scanned into a slice or a tuple can be paired with their names.
+func (rs *RowScanner) Columns() ([]string, error) {
+	if rs.columns == nil {
+		columns, err := rs.rows.Columns()
+		if err != nil {
+			return nil, errors.Wrap(err, "scany: get rows columns")
+		}
+		rs.columns = columns
+	}
+	return rs.columns, nil
+}
+
 func (rs *RowScanner) doScan(dstValue reflect.Value) error {
 	if !rs.started {
 		if err := rs.start(rs, dstValue); err != nil {
@@ -92,8 +142,38 @@ func startScanner(rs *RowScanner, dstValue reflect.Value) error {
 		return nil
 	}
 
+	if isTuple(dstType) {
+		if count := len(dstValue.Addr().Interface().(tuple).scanTargets()); count != len(rs.columns) {
+			return errors.Errorf(
+				"scany: to scan into %v, columns number must be exactly %d, got: %d",
+				dstType, count, len(rs.columns),
+			)
+		}
+		rs.scanFn = rs.scanTuple
+		return nil
+	}
+
+	if dstKind == reflect.Slice && dstType.Elem().Kind() == reflect.Interface {
+		rs.scanFn = rs.scanValues
+		return nil
+	}
+
 	if dstKind == reflect.Struct {
-		rs.columnToFieldIndex = rs.api.getColumnToFieldIndexMap(dstType)
+		if qualifiedRows, ok := rs.rows.(QualifiedRows); ok {
+			if rs.unqualified, err = qualifiedRows.UnqualifiedColumns(); err != nil {
+				return errors.Wrap(err, "scany: get rows columns")
+			}
+		}
+		if rs.api.strictScan {
+			if err := rs.api.validateColumns(dstType, rs.columns, rs.unqualified, rs.api.allowUnknownColumns); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		rs.structPlan, err = rs.api.getStructPlan(dstType, rs.columns, rs.unqualified)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		rs.initStructScans()
 		rs.scanFn = rs.scanStruct
 		return nil
 	}
@@ -120,31 +200,281 @@ func startScanner(rs *RowScanner, dstValue reflect.Value) error {
 	)
 }
 
-func (rs *RowScanner) scanStruct(structValue reflect.Value) error {
-	scans := make([]interface{}, len(rs.columns))
-	for i, column := range rs.columns {
-		fieldIndex, ok := rs.columnToFieldIndex[column]
+// getStructPlan returns the plan for scanning the columns into the struct type from the cache of the API,
+// building it if it's not there yet. The unqualified names are only given for QualifiedRows.
+func (api *API) getStructPlan(structType reflect.Type, columns []string, unqualified []string) (*structPlan, error) {
+	key := structPlanKey{structType: structType, columns: strings.Join(columns, "\x00")}
+	if unqualified != nil {
+		key.columns += "\x01" + strings.Join(unqualified, "\x00")
+	}
+	if plan, found := api.structPlans.Load(key); found {
+		return plan.(*structPlan), nil
+	}
+
+	targets, pointers, err := api.newStructTargets(structType, columns, unqualified)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	plan := &structPlan{targets: targets, pointers: pointers}
+	for _, target := range targets {
+		plan.hasHolders = plan.hasHolders || target.viaHolder
+	}
+	if !plan.hasHolders {
+		plan.fieldIndexes = make([][]int, len(targets))
+		for i, target := range targets {
+			plan.fieldIndexes[i] = target.fieldIndex
+		}
+	}
+	api.structPlans.Store(key, plan)
+	return plan, nil
+}
+
+// DirectFieldIndexes returns the index of the struct field that the value of each column is scanned into,
+// nil for the unknown columns which values are discarded, from the struct plan cached by the API.
+// It reports false when the values can't all be scanned straight into the fields: when the struct is aggregated
+// or scanned as a whole, when the columns repeat or don't match the fields, or when any of them is scanned via a holder,
+// like the fields with scan converters or the nullzero option and the fields of nested struct pointers.
+// Drivers use it to decode the values natively into the fields, falling back to ScanAll otherwise.
+func (api *API) DirectFieldIndexes(structType reflect.Type, columns []string) ([][]int, bool) {
+	if structType.Kind() != reflect.Struct || api.isScannableType(structType) || api.isAggregated(structType) {
+		return nil, false
+	}
+	if ensureDistinctColumns(columns) != nil {
+		return nil, false
+	}
+	if api.strictScan && api.validateColumns(structType, columns, nil, api.allowUnknownColumns) != nil {
+		return nil, false
+	}
+	plan, err := api.getStructPlan(structType, columns, nil)
+	if err != nil || plan.hasHolders {
+		return nil, false
+	}
+	return plan.fieldIndexes, true
+}
+
+// newStructTargets resolves the struct field of each column
+// and the nested struct pointers on the paths to the fields.
+func (api *API) newStructTargets(structType reflect.Type, columns []string, unqualified []string) ([]structTarget, *nestedPointers, error) {
+	columnToFieldIndex := api.getColumnToFieldIndexMap(structType)
+	targets := make([]structTarget, len(columns))
+	pointers := newNestedPointers(len(columns))
+	claimed := make(map[string]string, len(columns))
+	for _, column := range columns {
+		if _, ok := columnToFieldIndex[column]; ok {
+			claimed[column] = column
+		}
+	}
+	for i, column := range columns {
+		fieldIndex, ok := columnToFieldIndex[column]
+		if !ok && unqualified != nil && unqualified[i] != column {
+			// The rows qualify the duplicate column names with their tables, e.g. users.id and orders.id,
+			// which map to the field of the plain column name if only one column does.
+			plain := unqualified[i]
+			if fieldIndex, ok = columnToFieldIndex[plain]; ok {
+				if other, found := claimed[plain]; found {
+					return nil, nil, errors.Errorf(
+						"scany: columns: '%s' and '%s' are ambiguous, both map to the same field in %v",
+						other, column, structType,
+					)
+				}
+				claimed[plain] = column
+			}
+		}
 		if !ok {
-			if rs.api.allowUnknownColumns {
-				var tmp interface{}
-				scans[i] = &tmp
+			if api.allowUnknownColumns {
 				continue
 			}
-			return errors.Errorf(
+			return nil, nil, errors.Errorf(
 				"scany: column: '%s': no corresponding field found, or it's unexported in %v",
-				column, structValue.Type(),
+				column, structType,
 			)
 		}
-		// Struct may contain embedded structs by ptr that defaults to nil.
-		// In order to scan values into a nested field,
-		// we need to initialize all nil structs on its way.
-		initializeNested(structValue, fieldIndex)
+		field := structType.FieldByIndex(fieldIndex)
+		target := structTarget{
+			fieldIndex: fieldIndex,
+			fieldType:  field.Type,
+			nullZero:   api.nullZero || hasTagOption(api.tagOptions(field), "nullzero"),
+			groups:     pointers.add(i, structType, fieldIndex),
+		}
+		conv, _ := api.scanConverterOf(field.Type)
+		target.converted = conv != nil
+		// Nested structs behind pointers are only allocated when at least one of their columns is not NULL,
+		// so that the missing rows of outer joins are left nil.
+		target.viaHolder = len(target.groups) > 0 || target.converted ||
+			(target.nullZero && nullableType(field.Type) != field.Type)
+		targets[i] = target
+	}
+	return targets, pointers, nil
+}
+
+// nestedPointers keeps track of the nested structs behind pointers on the paths to the fields of a struct.
+type nestedPointers struct {
+	prefixes [][]int
+	parents  []int
+	// columnGroups are the indexes of the struct pointers on the path to the field of each column.
+	columnGroups [][]int
+}
+
+func newNestedPointers(columnCount int) *nestedPointers {
+	return &nestedPointers{columnGroups: make([][]int, columnCount)}
+}
+
+// add returns the indexes of the struct pointers on the path to the field of the column from the outermost one,
+// appending the ones that are new.
+func (np *nestedPointers) add(column int, structType reflect.Type, fieldIndex []int) []int {
+	var groups []int
+	parent := -1
+	t := structType
+	for i, index := range fieldIndex[:len(fieldIndex)-1] {
+		t = t.Field(index).Type
+		if t.Kind() != reflect.Ptr {
+			continue
+		}
+		t = t.Elem()
+
+		prefix := fieldIndex[:i+1]
+		group := -1
+		for g, groupPrefix := range np.prefixes {
+			if reflect.DeepEqual(groupPrefix, prefix) {
+				group = g
+			}
+		}
+		if group < 0 {
+			group = len(np.prefixes)
+			np.prefixes = append(np.prefixes, prefix)
+			np.parents = append(np.parents, parent)
+		}
+		groups = append(groups, group)
+		parent = group
+	}
+	np.columnGroups[column] = groups
+	return groups
+}
+
+// present reports for each of the struct pointers whether any of the columns behind it is not NULL,
+// reusing the present slice if it's given.
+func (np *nestedPointers) present(holders []reflect.Value, present []bool) []bool {
+	if present == nil {
+		present = make([]bool, len(np.prefixes))
+	}
+	for g := range present {
+		present[g] = false
+	}
+	for i, groups := range np.columnGroups {
+		if len(groups) == 0 || isNullHolder(holders[i]) {
+			continue
+		}
+		for _, group := range groups {
+			present[group] = true
+		}
+	}
+	return present
+}
+
+// clear sets the struct pointers which columns are all NULL to nil,
+// the pointers may have been set by scanning a previous row into the same struct.
+func (np *nestedPointers) clear(structValue reflect.Value, present []bool) {
+	for g, prefix := range np.prefixes {
+		if present[g] || (np.parents[g] >= 0 && !present[np.parents[g]]) {
+			continue
+		}
+		if len(prefix) > 1 {
+			initializeNested(structValue, prefix[:len(prefix)-1])
+		}
+		pointerField := structValue.FieldByIndex(prefix)
+		pointerField.Set(reflect.Zero(pointerField.Type()))
+	}
+}
+
+func allPresent(groups []int, present []bool) bool {
+	for _, group := range groups {
+		if !present[group] {
+			return false
+		}
+	}
+	return true
+}
+
+// initStructScans allocates the scan targets of the columns that are reused for every row,
+// the discarded values of unknown columns and the holders are scanned into the same values each time.
+func (rs *RowScanner) initStructScans() {
+	rs.scans = make([]interface{}, len(rs.columns))
+	if rs.structPlan.hasHolders {
+		rs.holders = make([]reflect.Value, len(rs.columns))
+		rs.present = make([]bool, len(rs.structPlan.pointers.prefixes))
+	}
+	for i, target := range rs.structPlan.targets {
+		switch {
+		case target.fieldIndex == nil:
+			var tmp interface{}
+			rs.scans[i] = &tmp
+		case target.converted:
+			rs.holders[i] = reflect.New(interfaceType)
+			rs.scans[i] = rs.holders[i].Interface()
+		case target.viaHolder:
+			rs.holders[i] = reflect.New(nullableType(target.fieldType))
+			rs.scans[i] = rs.holders[i].Interface()
+		}
+	}
+}
+
+func (rs *RowScanner) scanStruct(structValue reflect.Value) error {
+	for i, target := range rs.structPlan.targets {
+		if target.fieldIndex == nil {
+			continue
+		}
+		if target.viaHolder {
+			holder := rs.holders[i].Elem()
+			holder.Set(reflect.Zero(holder.Type()))
+			continue
+		}
+		// Fields behind pointers are always scanned via holders,
+		// so there are no nil structs on the way to the field.
+		rs.scans[i] = structValue.FieldByIndex(target.fieldIndex).Addr().Interface()
+	}
+	if err := rs.rows.Scan(rs.scans...); err != nil {
+		return errors.Wrap(err, "scany: scan row into struct fields")
+	}
+	if !rs.structPlan.hasHolders {
+		return nil
+	}
 
-		fieldVal := structValue.FieldByIndex(fieldIndex)
-		scans[i] = fieldVal.Addr().Interface()
+	present := rs.structPlan.pointers.present(rs.holders, rs.present)
+	for i, target := range rs.structPlan.targets {
+		if !target.viaHolder || !allPresent(target.groups, present) {
+			continue
+		}
+		if len(target.groups) > 0 {
+			initializeNested(structValue, target.fieldIndex)
+		}
+		field := structValue.FieldByIndex(target.fieldIndex)
+		if target.converted {
+			value, err := rs.api.convertScanned(target.fieldType, rs.holders[i].Elem().Interface())
+			if err != nil {
+				return errors.Wrapf(err, "scany: column: '%s'", rs.columns[i])
+			}
+			field.Set(value)
+			continue
+		}
+		if isNullHolder(rs.holders[i]) && nullableType(target.fieldType) != target.fieldType {
+			if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+				// Scanners like sql.NullString hold the NULL themselves.
+				if err := scanner.Scan(nil); err != nil {
+					return errors.Wrapf(err, "scany: scan row into struct fields: column: '%s'", rs.columns[i])
+				}
+				continue
+			}
+		}
+		if !target.nullZero && isNullHolder(rs.holders[i]) && nullableType(target.fieldType) != target.fieldType {
+			return errors.Errorf(
+				"scany: scan row into struct fields: column: '%s': can't scan NULL into %v field",
+				rs.columns[i], target.fieldType,
+			)
+		}
+		setFromHolder(field, rs.holders[i])
 	}
-	err := rs.rows.Scan(scans...)
-	return errors.Wrap(err, "scany: scan row into struct fields")
+	rs.structPlan.pointers.clear(structValue, present)
+	return nil
 }
 
 func (rs *RowScanner) scanMap(mapValue reflect.Value) error {
@@ -173,14 +503,48 @@ func (rs *RowScanner) scanMap(mapValue reflect.Value) error {
 	return nil
 }
 
+func (rs *RowScanner) scanTuple(tupleValue reflect.Value) error {
+	err := rs.rows.Scan(tupleValue.Addr().Interface().(tuple).scanTargets()...)
+	return errors.Wrap(err, "scany: scan row into tuple")
+}
+
+func (rs *RowScanner) scanValues(sliceValue reflect.Value) error {
+	values := reflect.MakeSlice(sliceValue.Type(), len(rs.columns), len(rs.columns))
+	scans := make([]interface{}, len(rs.columns))
+	for i := range scans {
+		scans[i] = values.Index(i).Addr().Interface()
+	}
+	if err := rs.rows.Scan(scans...); err != nil {
+		return errors.Wrap(err, "scany: scan row into slice")
+	}
+	sliceValue.Set(values)
+	return nil
+}
+
 func (rs *RowScanner) scanPrimitive(value reflect.Value) error {
+	if conv, _ := rs.api.scanConverterOf(value.Type()); conv != nil {
+		var src interface{}
+		if err := rs.rows.Scan(&src); err != nil {
+			return errors.Wrap(err, "scany: scan row value into a primitive type")
+		}
+		converted, err := rs.api.convertScanned(value.Type(), src)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		value.Set(converted)
+		return nil
+	}
 	err := rs.rows.Scan(value.Addr().Interface())
 	return errors.Wrap(err, "scany: scan row value into a primitive type")
 }
 
 func (rs *RowScanner) ensureDistinctColumns() error {
-	seen := make(map[string]struct{}, len(rs.columns))
-	for _, column := range rs.columns {
+	return ensureDistinctColumns(rs.columns)
+}
+
+func ensureDistinctColumns(columns []string) error {
+	seen := make(map[string]struct{}, len(columns))
+	for _, column := range columns {
 		if _, ok := seen[column]; ok {
 			return errors.Errorf("scany: rows contain a duplicate column '%s'", column)
 		}
@@ -0,0 +1,605 @@
+package dbquery
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// fakeRows is an in-memory Rows implementation,
+// it converts the values like database/sql does for the common cases.
+type fakeRows struct {
+	columns []string
+	values  [][]interface{}
+	pos     int
+	closed  bool
+}
+
+func newFakeRows(columns []string, values ...[]interface{}) *fakeRows {
+	return &fakeRows{columns: columns, values: values}
+}
+
+func (r *fakeRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *fakeRows) Err() error {
+	return nil
+}
+
+func (r *fakeRows) Next() bool {
+	if r.closed || r.pos >= len(r.values) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *fakeRows) Columns() ([]string, error) {
+	return r.columns, nil
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	row := r.values[r.pos-1]
+	if len(dest) != len(row) {
+		return errors.Errorf("expected %d destination arguments in Scan, not %d", len(row), len(dest))
+	}
+	for i, d := range dest {
+		if err := assignFake(d, row[i]); err != nil {
+			return errors.Wrapf(err, "column %d", i)
+		}
+	}
+	return nil
+}
+
+func assignFake(dest interface{}, src interface{}) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+
+	dst := reflect.ValueOf(dest).Elem()
+	if src == nil {
+		switch dst.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		return errors.Errorf("converting NULL to %s is unsupported", dst.Kind())
+	}
+
+	srcVal := reflect.ValueOf(src)
+	if dst.Kind() == reflect.Ptr && !srcVal.Type().AssignableTo(dst.Type()) {
+		elem := reflect.New(dst.Type().Elem())
+		if err := assignFake(elem.Interface(), src); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+	if srcVal.Type().AssignableTo(dst.Type()) {
+		dst.Set(srcVal)
+		return nil
+	}
+	if srcVal.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(srcVal.Convert(dst.Type()))
+		return nil
+	}
+	return errors.Errorf("unsupported conversion from %T to %s", src, dst.Type())
+}
+
+type testAddress struct {
+	City   string
+	Street string
+}
+
+type testUser struct {
+	ID      int64
+	Name    string
+	Address testAddress
+}
+
+func TestScanAll(t *testing.T) {
+	rows := newFakeRows(
+		[]string{"id", "name", "address.city", "address.street"},
+		[]interface{}{int64(1), "bob", "Helsinki", "Mannerheimintie"},
+		[]interface{}{int64(2), "alice", "Turku", "Aurakatu"},
+	)
+
+	var got []*testUser
+	if err := DefaultAPI.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := []*testUser{
+		{ID: 1, Name: "bob", Address: testAddress{City: "Helsinki", Street: "Mannerheimintie"}},
+		{ID: 2, Name: "alice", Address: testAddress{City: "Turku", Street: "Aurakatu"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+
+	if !rows.closed {
+		t.Error("Expected rows to be closed")
+	}
+}
+
+func TestScanStrict(t *testing.T) {
+	api, err := NewAPI(WithStrictScan())
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	rows := newFakeRows([]string{"id", "name", "address.city"}, []interface{}{int64(1), "bob", "Helsinki"})
+
+	var got testUser
+	err = api.ScanOne(&got, rows)
+
+	var dstErr *DestinationError
+	if !errors.As(err, &dstErr) {
+		t.Fatalf("Expected a DestinationError, but got: %v", err)
+	}
+
+	if !reflect.DeepEqual(dstErr.MissingColumns, []string{"address.street"}) {
+		t.Errorf("Expected missing columns to be [address.street], but got: %v", dstErr.MissingColumns)
+	}
+}
+
+func TestScanStrictNullString(t *testing.T) {
+	type Profile struct {
+		ID   int64
+		Nick sql.NullString
+	}
+
+	api, err := NewAPI(WithStrictScan())
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	rows := newFakeRows([]string{"id", "nick"}, []interface{}{int64(1), "bob"})
+
+	var got Profile
+	if err := api.ScanOne(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := Profile{ID: 1, Nick: sql.NullString{String: "bob", Valid: true}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+}
+
+func TestValidateDestination(t *testing.T) {
+	err := ValidateDestination([]string{"id", "nick", "address.city", "address.street"}, &[]testUser{})
+
+	expectedErr := "scany: columns do not match the fields of dbquery.testUser, " +
+		"no corresponding field for columns: nick, no corresponding column for fields: name"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected error: '%s', but got: '%v'", expectedErr, err)
+	}
+
+	err = ValidateDestination([]string{"name", "id", "address.street", "address.city"}, testUser{})
+	if err != nil {
+		t.Error("Expected columns to match, but got: " + err.Error())
+	}
+}
+
+func TestScanNullZeroTag(t *testing.T) {
+	type Profile struct {
+		ID   int64
+		Nick string `db:"nick,nullzero"`
+		Bio  string
+	}
+
+	rows := newFakeRows([]string{"id", "nick", "bio"}, []interface{}{int64(1), nil, "hello"})
+
+	var got Profile
+	if err := DefaultAPI.ScanOne(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := Profile{ID: 1, Bio: "hello"}
+	if got != expected {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+
+	rows = newFakeRows([]string{"id", "nick", "bio"}, []interface{}{int64(1), "bob", nil})
+	if err := DefaultAPI.ScanOne(&got, rows); err == nil {
+		t.Error("Expected NULL to fail the scan of a field without the nullzero option")
+	}
+}
+
+func TestScanNullZero(t *testing.T) {
+	api, err := NewAPI(WithNullZero())
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	type Person struct {
+		ID      int64
+		Name    string
+		Address *testAddress
+	}
+
+	rows := newFakeRows(
+		[]string{"id", "name", "address.city", "address.street"},
+		[]interface{}{int64(1), nil, "Helsinki", nil},
+		[]interface{}{int64(2), "alice", nil, nil},
+	)
+
+	var got []Person
+	if err := api.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := []Person{
+		{ID: 1, Address: &testAddress{City: "Helsinki"}},
+		{ID: 2, Name: "alice"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+}
+
+func TestScanNestedPointerScanner(t *testing.T) {
+	type Addr struct {
+		City sql.NullString
+		Zip  string
+	}
+
+	type Person struct {
+		ID   int64
+		Addr *Addr
+	}
+
+	rows := newFakeRows(
+		[]string{"id", "addr.city", "addr.zip"},
+		[]interface{}{int64(1), "Helsinki", "00100"},
+		[]interface{}{int64(2), nil, "00200"},
+		[]interface{}{int64(3), nil, nil},
+	)
+
+	var got []Person
+	if err := DefaultAPI.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := []Person{
+		{ID: 1, Addr: &Addr{City: sql.NullString{String: "Helsinki", Valid: true}, Zip: "00100"}},
+		{ID: 2, Addr: &Addr{Zip: "00200"}},
+		{ID: 3},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+}
+
+func TestScanNestedPointerNil(t *testing.T) {
+	type Country struct {
+		Code string
+	}
+
+	type Location struct {
+		City    string
+		Country *Country
+	}
+
+	type Person struct {
+		ID       int64
+		Location *Location
+	}
+
+	rows := newFakeRows(
+		[]string{"id", "location.city", "location.country.code"},
+		[]interface{}{int64(1), "Helsinki", "FI"},
+		[]interface{}{int64(2), nil, nil},
+		[]interface{}{int64(3), "Atlantis", nil},
+	)
+
+	var got []Person
+	if err := DefaultAPI.ScanAll(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := []Person{
+		{ID: 1, Location: &Location{City: "Helsinki", Country: &Country{Code: "FI"}}},
+		{ID: 2},
+		{ID: 3, Location: &Location{City: "Atlantis"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+
+	// Pointers set by a previous scan into the same struct are cleared.
+	dst := expected[0]
+	rows = newFakeRows([]string{"id", "location.city", "location.country.code"}, []interface{}{int64(2), nil, nil})
+	if err := DefaultAPI.ScanOne(&dst, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+	if dst.Location != nil {
+		t.Errorf("Expected location to be nil, but got: %+v", dst.Location)
+	}
+
+	rows = newFakeRows([]string{"id", "location.city", "location.country.code"}, []interface{}{int64(4), nil, "SE"})
+	if err := DefaultAPI.ScanOne(&dst, rows); err == nil {
+		t.Error("Expected NULL to fail the scan of a field without the nullzero option")
+	}
+}
+
+func TestScanAllSchemaless(t *testing.T) {
+	newRows := func() *fakeRows {
+		return newFakeRows(
+			[]string{"status", "count"},
+			[]interface{}{"paid", int64(3)},
+			[]interface{}{nil, int64(1)},
+		)
+	}
+
+	var maps []map[string]interface{}
+	if err := DefaultAPI.ScanAll(&maps, newRows()); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+	expectedMaps := []map[string]interface{}{
+		{"status": "paid", "count": int64(3)},
+		{"status": nil, "count": int64(1)},
+	}
+	if !reflect.DeepEqual(maps, expectedMaps) {
+		t.Errorf("Expected: %v, but got: %v", expectedMaps, maps)
+	}
+
+	var values [][]interface{}
+	if err := DefaultAPI.ScanAll(&values, newRows()); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+	expectedValues := [][]interface{}{{"paid", int64(3)}, {nil, int64(1)}}
+	if !reflect.DeepEqual(values, expectedValues) {
+		t.Errorf("Expected: %v, but got: %v", expectedValues, values)
+	}
+
+	var tuples []Row2[*string, int64]
+	if err := DefaultAPI.ScanAll(&tuples, newRows()); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+	if len(tuples) != 2 || *tuples[0].V1 != "paid" || tuples[0].V2 != 3 || tuples[1].V1 != nil || tuples[1].V2 != 1 {
+		t.Errorf("Expected tuples to be [{paid 3} {<nil> 1}], but got: %+v", tuples)
+	}
+
+	var triples []Row3[string, int64, int64]
+	if err := DefaultAPI.ScanAll(&triples, newRows()); err == nil {
+		t.Error("Expected an error for a tuple with more values than columns")
+	}
+}
+
+func TestRowScannerColumns(t *testing.T) {
+	rows := newFakeRows([]string{"id", "name"}, []interface{}{int64(1), "bob"})
+	rs := NewRowScanner(rows)
+
+	columns, err := rs.Columns()
+	if err != nil {
+		t.Fatal("Errored while trying to get columns", err)
+	}
+	if !reflect.DeepEqual(columns, []string{"id", "name"}) {
+		t.Errorf("Expected columns to be [id name], but got: %v", columns)
+	}
+
+	rows.Next()
+	var values []interface{}
+	if err := rs.Scan(&values); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+	if !reflect.DeepEqual(values, []interface{}{int64(1), "bob"}) {
+		t.Errorf("Expected values to be [1 bob], but got: %v", values)
+	}
+}
+
+// qualifiedFakeRows qualifies the columns like the rows of a driver implementing QualifiedRows.
+type qualifiedFakeRows struct {
+	*fakeRows
+	unqualified []string
+}
+
+func (r *qualifiedFakeRows) UnqualifiedColumns() ([]string, error) {
+	return r.unqualified, nil
+}
+
+func TestScanQualifiedColumns(t *testing.T) {
+	type Order struct {
+		ID    int64
+		Total int64
+	}
+
+	type UserOrder struct {
+		testUser
+		Order Order `db:"orders"`
+	}
+
+	// users.id falls back to the id field, orders.id maps to the nested struct.
+	rows := &qualifiedFakeRows{
+		fakeRows: newFakeRows(
+			[]string{"users.id", "name", "orders.id", "orders.total"},
+			[]interface{}{int64(1), "bob", int64(10), int64(100)},
+		),
+		unqualified: []string{"id", "name", "id", "total"},
+	}
+
+	var got UserOrder
+	if err := DefaultAPI.ScanOne(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := UserOrder{testUser: testUser{ID: 1, Name: "bob"}, Order: Order{ID: 10, Total: 100}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+
+	rows = &qualifiedFakeRows{
+		fakeRows:    newFakeRows([]string{"users.id", "orders.id"}, []interface{}{int64(1), int64(10)}),
+		unqualified: []string{"id", "id"},
+	}
+	var order Order
+	if err := DefaultAPI.ScanOne(&order, rows); err == nil {
+		t.Error("Expected an error for the columns mapping to the same field")
+	}
+
+	// The rows of other drivers don't qualify the columns, so the dotted columns don't fall back.
+	plain := newFakeRows([]string{"orders.id", "total"}, []interface{}{int64(10), int64(100)})
+	if err := DefaultAPI.ScanOne(&order, plain); err == nil {
+		t.Error("Expected an error for the dotted column without a nested struct")
+	}
+}
+
+func TestScanStrictQualifiedColumns(t *testing.T) {
+	api, err := NewAPI(WithStrictScan())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := &qualifiedFakeRows{
+		fakeRows: newFakeRows(
+			[]string{"users.id", "name", "orders.id"},
+			[]interface{}{int64(1), "bob", int64(10)},
+		),
+		unqualified: []string{"id", "name", "id"},
+	}
+
+	type User struct {
+		ID   int64
+		Name string
+	}
+
+	var got User
+	if err := api.ScanOne(&got, rows); err == nil {
+		t.Error("Expected an error for the columns mapping to the same field")
+	}
+
+	rows = &qualifiedFakeRows{
+		fakeRows:    newFakeRows([]string{"users.id", "name"}, []interface{}{int64(1), "bob"}),
+		unqualified: []string{"id", "name"},
+	}
+	if err := api.ScanOne(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+	if got != (User{ID: 1, Name: "bob"}) {
+		t.Errorf("Expected: %+v, but got: %+v", User{ID: 1, Name: "bob"}, got)
+	}
+}
+
+func TestDirectFieldIndexes(t *testing.T) {
+	columns := []string{"id", "name", "address.city", "address.street"}
+	got, ok := DefaultAPI.DirectFieldIndexes(reflect.TypeOf(testUser{}), columns)
+	expected := [][]int{{0}, {1}, {2, 0}, {2, 1}}
+	if !ok || !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %v, but got: %v, %v", expected, got, ok)
+	}
+
+	type Person struct {
+		ID      int64
+		Address *testAddress
+	}
+	if _, ok := DefaultAPI.DirectFieldIndexes(reflect.TypeOf(Person{}), []string{"id", "address.city"}); ok {
+		t.Error("Expected the fields of a nested struct pointer not to be scanned directly")
+	}
+
+	if _, ok := DefaultAPI.DirectFieldIndexes(reflect.TypeOf(testUser{}), []string{"id", "id"}); ok {
+		t.Error("Expected duplicate columns not to be scanned directly")
+	}
+
+	if _, ok := DefaultAPI.DirectFieldIndexes(reflect.TypeOf(testCustomer{}), []string{"id", "name"}); ok {
+		t.Error("Expected an aggregated struct not to be scanned directly")
+	}
+
+	if _, ok := DefaultAPI.DirectFieldIndexes(reflect.TypeOf(testUser{}), []string{"id", "extra"}); ok {
+		t.Error("Expected an unknown column not to be scanned directly")
+	}
+}
+
+func TestScanFirst(t *testing.T) {
+	rows := newFakeRows(
+		[]string{"id", "name", "address.city", "address.street"},
+		[]interface{}{int64(1), "bob", "Helsinki", "Mannerheimintie"},
+		[]interface{}{int64(2), "alice", "Turku", "Aurakatu"},
+	)
+
+	var got testUser
+	if err := DefaultAPI.ScanFirst(&got, rows); err != nil {
+		t.Fatal("Errored while trying to scan", err)
+	}
+
+	expected := testUser{ID: 1, Name: "bob", Address: testAddress{City: "Helsinki", Street: "Mannerheimintie"}}
+	if got != expected {
+		t.Errorf("Expected: %+v, but got: %+v", expected, got)
+	}
+	if !rows.closed || rows.pos != 1 {
+		t.Errorf("Expected rows to be closed after the first row, but got: closed %v at row %d", rows.closed, rows.pos)
+	}
+
+	rows = newFakeRows([]string{"id", "name", "address.city", "address.street"})
+	if err := DefaultAPI.ScanFirst(&got, rows); !NotFound(err) {
+		t.Errorf("Expected a not found error, but got: %v", err)
+	}
+}
+
+func TestScanOptional(t *testing.T) {
+	rows := newFakeRows([]string{"id"})
+
+	var id int64
+	found, err := DefaultAPI.ScanOptional(&id, rows)
+	if err != nil || found {
+		t.Errorf("Expected no row to be found without an error, but got: %v, %v", found, err)
+	}
+
+	rows = newFakeRows([]string{"id"}, []interface{}{int64(7)})
+	found, err = DefaultAPI.ScanOptional(&id, rows)
+	if err != nil || !found || id != 7 {
+		t.Errorf("Expected 7 to be found, but got: %v, %v, %v", id, found, err)
+	}
+
+	rows = newFakeRows([]string{"id"}, []interface{}{int64(7)}, []interface{}{int64(8)})
+	if _, err := DefaultAPI.ScanOptional(&id, rows); err == nil {
+		t.Error("Expected an error for more than one row")
+	}
+}
+
+func benchmarkRows(count int) [][]interface{} {
+	values := make([][]interface{}, count)
+	for i := range values {
+		values[i] = []interface{}{int64(i), "bob", "Helsinki", "Mannerheimintie"}
+	}
+	return values
+}
+
+func BenchmarkScanAll(b *testing.B) {
+	values := benchmarkRows(100000)
+	columns := []string{"id", "name", "address.city", "address.street"}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var users []testUser
+		if err := DefaultAPI.ScanAll(&users, newFakeRows(columns, values...)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScanAllNestedPointer(b *testing.B) {
+	type Person struct {
+		ID      int64
+		Name    string
+		Address *testAddress
+	}
+
+	values := benchmarkRows(100000)
+	columns := []string{"id", "name", "address.city", "address.street"}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var people []Person
+		if err := DefaultAPI.ScanAll(&people, newFakeRows(columns, values...)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
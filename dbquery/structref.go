@@ -1,9 +1,13 @@
 package dbquery
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -133,3 +137,97 @@ func initializeNested(structValue reflect.Value, fieldIndex []int) {
 		initializeNested(reflect.Indirect(field), fieldIndex[1:])
 	}
 }
+
+// leafColumns returns the columns of the struct type that are mapped to fields which values are scanned,
+// leaving out the columns of the nested structs that only group other columns
+// and the columns of the fields of value structs like sql.NullString and time.Time.
+func (api *API) leafColumns(structType reflect.Type) []string {
+	columnToFieldIndex := api.getColumnToFieldIndexMap(structType)
+
+	values := make(map[string]bool, len(columnToFieldIndex))
+	for column, index := range columnToFieldIndex {
+		values[column] = api.isValueType(structType.FieldByIndex(index).Type)
+	}
+
+	parents := make(map[string]struct{}, len(columnToFieldIndex))
+	leaves := make([]string, 0, len(columnToFieldIndex))
+	for column := range columnToFieldIndex {
+		inValue := false
+		for i := strings.LastIndex(column, api.columnSeparator); i > 0; i = strings.LastIndex(column[:i], api.columnSeparator) {
+			parent := column[:i]
+			parents[parent] = struct{}{}
+			if values[parent] {
+				inValue = true
+			}
+		}
+		if !inValue {
+			leaves = append(leaves, column)
+		}
+	}
+
+	filtered := leaves[:0]
+	for _, column := range leaves {
+		if _, isParent := parents[column]; !isParent || values[column] {
+			filtered = append(filtered, column)
+		}
+	}
+	sort.Strings(filtered)
+	return filtered
+}
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// isValueType reports whether the type holds a single value even though it may be a struct,
+// so that its fields are not columns of their own.
+func (api *API) isValueType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == timeType || api.isScannableType(t) || reflect.PtrTo(t).Implements(scannerType) || t.Implements(valuerType)
+}
+
+// LeafColumns returns the columns of the struct type that are mapped to fields holding values, in the order of the fields,
+// and the indexes of the fields. The nested structs that only group other columns,
+// and the slices of structs that are aggregated from joined rows are left out.
+func (api *API) LeafColumns(structType reflect.Type) ([]string, [][]int) {
+	columnToFieldIndex := api.getColumnToFieldIndexMap(structType)
+	columns := api.leafColumns(structType)
+	filtered := columns[:0]
+	for _, column := range columns {
+		if !api.isStructSlice(structType.FieldByIndex(columnToFieldIndex[column]).Type) {
+			filtered = append(filtered, column)
+		}
+	}
+	columns = filtered
+
+	sort.Slice(columns, func(i, j int) bool {
+		a, b := columnToFieldIndex[columns[i]], columnToFieldIndex[columns[j]]
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	fieldIndexes := make([][]int, len(columns))
+	for i, column := range columns {
+		fieldIndexes[i] = columnToFieldIndex[column]
+	}
+	return columns, fieldIndexes
+}
+
+func (api *API) isStructSlice(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+	elemType := t.Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	return elemType.Kind() == reflect.Struct && !api.isScannableType(t.Elem())
+}
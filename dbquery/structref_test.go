@@ -1,13 +1,67 @@
 package dbquery
 
-import "testing"
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
 
 func TestStructRefTreeModel(t *testing.T) {
 	type Node struct {
 		Value    any
-		Children Node
+		Children []Node
 	}
 
 
 	
 }
+
+func TestLeafColumns(t *testing.T) {
+	type Tag struct {
+		Label string
+	}
+
+	type Post struct {
+		ID      int64
+		Title   string
+		Secret  string `db:"-"`
+		Address *testAddress
+		Tags    []Tag
+		Author  string `db:"author_name"`
+	}
+
+	columns, fieldIndexes := DefaultAPI.LeafColumns(reflect.TypeOf(Post{}))
+
+	expected := []string{"id", "title", "address.city", "address.street", "author_name"}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("Expected: %v, but got: %v", expected, columns)
+	}
+
+	expectedIndexes := [][]int{{0}, {1}, {3, 0}, {3, 1}, {5}}
+	if !reflect.DeepEqual(fieldIndexes, expectedIndexes) {
+		t.Errorf("Expected: %v, but got: %v", expectedIndexes, fieldIndexes)
+	}
+}
+
+func TestLeafColumnsValueStructs(t *testing.T) {
+	type Event struct {
+		ID      int64
+		Name    sql.NullString
+		At      time.Time
+		EndedAt *sql.NullTime
+		Address testAddress
+	}
+
+	columns, _ := DefaultAPI.LeafColumns(reflect.TypeOf(Event{}))
+
+	expected := []string{"id", "name", "at", "ended_at", "address.city", "address.street"}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("Expected: %v, but got: %v", expected, columns)
+	}
+
+	err := ValidateDestination([]string{"id", "name", "at", "ended_at", "address.city", "address.street"}, &Event{})
+	if err != nil {
+		t.Error("Expected columns to match, but got: " + err.Error())
+	}
+}
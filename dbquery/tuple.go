@@ -0,0 +1,41 @@
+package dbquery
+
+import (
+	"reflect"
+)
+
+// tuple is implemented by the destinations that are scanned column by column in order,
+// regardless of the column names.
+type tuple interface {
+	scanTargets() []interface{}
+}
+
+var tupleType = reflect.TypeOf((*tuple)(nil)).Elem()
+
+// Row2 is a destination for rows of exactly two columns, scanned in order into V1 and V2, for example:
+//
+//	var counts []dbquery.Row2[string, int64]
+//	// SELECT status, count(*) FROM orders GROUP BY status
+type Row2[A, B any] struct {
+	V1 A
+	V2 B
+}
+
+func (r *Row2[A, B]) scanTargets() []interface{} {
+	return []interface{}{&r.V1, &r.V2}
+}
+
+// Row3 is a destination for rows of exactly three columns, scanned in order into V1, V2 and V3.
+type Row3[A, B, C any] struct {
+	V1 A
+	V2 B
+	V3 C
+}
+
+func (r *Row3[A, B, C]) scanTargets() []interface{} {
+	return []interface{}{&r.V1, &r.V2, &r.V3}
+}
+
+func isTuple(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(tupleType)
+}
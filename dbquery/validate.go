@@ -0,0 +1,89 @@
+package dbquery
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DestinationError describes the mismatches between the columns of a result and the fields of a destination struct.
+type DestinationError struct {
+	Type reflect.Type
+	// UnknownColumns are the columns that have no corresponding field in the struct.
+	UnknownColumns []string
+	// MissingColumns are the columns of the struct fields that are not in the result.
+	MissingColumns []string
+}
+
+func (e *DestinationError) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString("scany: columns do not match the fields of ")
+	sb.WriteString(e.Type.String())
+	if len(e.UnknownColumns) > 0 {
+		sb.WriteString(", no corresponding field for columns: ")
+		sb.WriteString(strings.Join(e.UnknownColumns, ", "))
+	}
+	if len(e.MissingColumns) > 0 {
+		sb.WriteString(", no corresponding column for fields: ")
+		sb.WriteString(strings.Join(e.MissingColumns, ", "))
+	}
+	return sb.String()
+}
+
+// ValidateDestination is a package-level helper function that uses the DefaultAPI object.
+// See API.ValidateDestination for details.
+func ValidateDestination(columns []string, dst interface{}) error {
+	return errors.WithStack(DefaultAPI.ValidateDestination(columns, dst))
+}
+
+// ValidateDestination checks that every column has a corresponding field in the destination struct
+// and that every field of the struct has a corresponding column.
+// The destination can be a struct or a slice of structs, by value or by a pointer.
+// It returns a *DestinationError listing all of the mismatches in both directions.
+func (api *API) ValidateDestination(columns []string, dst interface{}) error {
+	dstType := reflect.TypeOf(dst)
+	for dstType != nil && (dstType.Kind() == reflect.Ptr || dstType.Kind() == reflect.Slice) {
+		dstType = dstType.Elem()
+	}
+	if dstType == nil || dstType.Kind() != reflect.Struct {
+		return errors.Errorf("scany: destination must be a struct or a slice of structs, got: %T", dst)
+	}
+
+	return api.validateColumns(dstType, columns, nil, false /* allowUnknownColumns */)
+}
+
+// validateColumns returns a *DestinationError if the columns and the struct fields don't match,
+// the unknown columns are left out of it when allowUnknownColumns is set.
+// The qualified columns of QualifiedRows match the fields of their unqualified names.
+func (api *API) validateColumns(structType reflect.Type, columns []string, unqualified []string, allowUnknownColumns bool) error {
+	columnToFieldIndex := api.getColumnToFieldIndexMap(structType)
+	dstErr := &DestinationError{Type: structType}
+
+	seen := make(map[string]struct{}, len(columns))
+	for i, column := range columns {
+		name := column
+		if _, ok := columnToFieldIndex[column]; !ok && unqualified != nil {
+			if _, ok := columnToFieldIndex[unqualified[i]]; ok {
+				name = unqualified[i]
+			}
+		}
+		seen[name] = struct{}{}
+		if _, ok := columnToFieldIndex[name]; !ok && !allowUnknownColumns {
+			dstErr.UnknownColumns = append(dstErr.UnknownColumns, column)
+		}
+	}
+
+	for _, column := range api.leafColumns(structType) {
+		if _, ok := seen[column]; !ok {
+			dstErr.MissingColumns = append(dstErr.MissingColumns, column)
+		}
+	}
+
+	if len(dstErr.UnknownColumns) == 0 && len(dstErr.MissingColumns) == 0 {
+		return nil
+	}
+	sort.Strings(dstErr.UnknownColumns)
+	return dstErr
+}
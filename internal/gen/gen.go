@@ -0,0 +1,256 @@
+// Package gen generates typed Go functions from named queries in .sql files.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/anton7r/orava/dbquery"
+	"github.com/anton7r/orava/internal/typemap"
+	"github.com/pkg/errors"
+)
+
+// Driver is the orava package the generated code calls.
+type Driver string
+
+const (
+	DriverPgx Driver = "pgx"
+	DriverSQL Driver = "sql"
+)
+
+// Config describes what to generate.
+type Config struct {
+	// Pkg is the package the code is generated into, the params and result types are looked up from it.
+	Pkg     *types.Package
+	Driver  Driver
+	Queries []dbquery.QueryDef
+	// API compiles the named queries, its lexer must match the placeholders of the driver.
+	API    *dbquery.API
+	Mapper typemap.Mapper
+}
+
+type query struct {
+	def        dbquery.QueryDef
+	sqlConst   string
+	compiled   string
+	paramsType string
+	resultType string
+	args       []string
+}
+
+// Generate returns the formatted Go source for the queries.
+// Each query needs a "-- params: <Type>" annotation when it has named params
+// and a "-- result: <Type>" annotation when it is :one or :many.
+func Generate(cfg Config) ([]byte, error) {
+	if cfg.Driver != DriverPgx && cfg.Driver != DriverSQL {
+		return nil, errors.Errorf("orava gen: unknown driver '%s'", cfg.Driver)
+	}
+
+	var queries []query
+	var errs []string
+	for _, def := range cfg.Queries {
+		q, err := cfg.resolve(def)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("orava gen: %s:%d: %s: %s", def.File, def.Line, def.Name, err))
+			continue
+		}
+		queries = append(queries, q)
+	}
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "\n"))
+	}
+
+	buf := &bytes.Buffer{}
+	cfg.writeHeader(buf, queries)
+	for _, q := range queries {
+		cfg.writeQuery(buf, q)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "orava gen: format generated code")
+	}
+	return src, nil
+}
+
+func (cfg Config) resolve(def dbquery.QueryDef) (query, error) {
+	prep, err := cfg.API.PrepareNamed(def.SQL)
+	if err != nil {
+		return query{}, err
+	}
+	if idents := prep.Idents(); len(idents) > 0 {
+		return query{}, errors.Errorf("identifiers are not supported, found {{%s}}", idents[0])
+	}
+
+	q := query{
+		def:        def,
+		sqlConst:   lowerFirst(def.Name) + "SQL",
+		compiled:   prep.Query(),
+		paramsType: def.Meta["params"],
+		resultType: def.Meta["result"],
+	}
+
+	params := prep.NamedParams()
+	if len(params) > 0 {
+		if q.paramsType == "" {
+			return query{}, errors.New("query has named params but no '-- params: <Type>' annotation")
+		}
+		st, err := cfg.lookupStruct(q.paramsType)
+		if err != nil {
+			return query{}, err
+		}
+		columns := cfg.Mapper.Columns(st)
+		var missing []string
+		for _, param := range params {
+			field, found := columns[param]
+			if !found || field.InSlice {
+				missing = append(missing, "field '"+param+"' was not found from '"+q.paramsType+"' struct")
+				continue
+			}
+			if field.ThroughPointer {
+				missing = append(missing, "field '"+param+"' of '"+q.paramsType+"' is behind a pointer")
+				continue
+			}
+			q.args = append(q.args, "arg."+field.Selector())
+		}
+		if len(missing) > 0 {
+			return query{}, errors.New(strings.Join(missing, ", "))
+		}
+	} else if q.paramsType != "" {
+		if _, err := cfg.lookupStruct(q.paramsType); err != nil {
+			return query{}, err
+		}
+	}
+
+	switch def.Kind {
+	case dbquery.QueryOne, dbquery.QueryMany:
+		if q.resultType == "" {
+			return query{}, errors.Errorf("%s query has no '-- result: <Type>' annotation", def.Kind)
+		}
+		if cfg.lookupType(q.resultType) == nil {
+			return query{}, errors.Errorf("result type '%s' was not found", q.resultType)
+		}
+	case dbquery.QueryExec:
+		if q.resultType != "" {
+			return query{}, errors.New(":exec query can not have a result type")
+		}
+	}
+
+	return q, nil
+}
+
+func (cfg Config) lookupType(name string) types.Type {
+	if obj, ok := cfg.Pkg.Scope().Lookup(name).(*types.TypeName); ok {
+		return obj.Type()
+	}
+	if obj, ok := types.Universe.Lookup(name).(*types.TypeName); ok {
+		return obj.Type()
+	}
+	return nil
+}
+
+func (cfg Config) lookupStruct(name string) (*types.Struct, error) {
+	t := cfg.lookupType(name)
+	if t == nil {
+		return nil, errors.Errorf("params type '%s' was not found", name)
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil, errors.Errorf("params type '%s' is not a struct", name)
+	}
+	return st, nil
+}
+
+func (cfg Config) writeHeader(buf *bytes.Buffer, queries []query) {
+	fmt.Fprintf(buf, "// Code generated by orava gen. DO NOT EDIT.\n\npackage %s\n\n", cfg.Pkg.Name())
+
+	hasExec := false
+	for _, q := range queries {
+		if q.def.Kind == dbquery.QueryExec {
+			hasExec = true
+		}
+	}
+
+	buf.WriteString("import (\n\t\"context\"\n")
+	if hasExec && cfg.Driver == DriverSQL {
+		buf.WriteString("\t\"database/sql\"\n")
+	}
+	buf.WriteString("\n\t\"github.com/anton7r/orava/" + cfg.pkgName() + "\"\n")
+	if hasExec && cfg.Driver == DriverPgx {
+		buf.WriteString("\t\"github.com/jackc/pgx/v5/pgconn\"\n")
+	}
+	buf.WriteString(")\n\n")
+
+	fmt.Fprintf(buf, `// Queries runs the generated queries, scanning the results with the configuration of the API.
+type Queries struct {
+	api *%[1]s.API
+}
+
+// NewQueries returns a new Queries instance.
+func NewQueries(api *%[1]s.API) *Queries {
+	return &Queries{api: api}
+}
+
+`, cfg.pkgName())
+}
+
+func (cfg Config) pkgName() string {
+	if cfg.Driver == DriverPgx {
+		return "pgxquery"
+	}
+	return "sqlquery"
+}
+
+func (cfg Config) writeQuery(buf *bytes.Buffer, q query) {
+	fmt.Fprintf(buf, "const %s = %s\n\n", q.sqlConst, quoteSQL(q.compiled))
+
+	params := "ctx context.Context, db " + cfg.pkgName() + ".Querier"
+	if q.paramsType != "" {
+		params += ", arg " + q.paramsType
+	}
+	args := q.sqlConst
+	if len(q.args) > 0 {
+		args += ", " + strings.Join(q.args, ", ")
+	}
+
+	fmt.Fprintf(buf, "// %s runs the %s query from %s.\n", q.def.Name, q.def.Kind, q.def.File)
+	switch q.def.Kind {
+	case dbquery.QueryOne:
+		fmt.Fprintf(buf, "func (q *Queries) %s(%s) (%s, error) {\n", q.def.Name, params, q.resultType)
+		fmt.Fprintf(buf, "\tvar dst %s\n\terr := q.api.Get(ctx, db, &dst, %s)\n\treturn dst, err\n}\n\n", q.resultType, args)
+	case dbquery.QueryMany:
+		fmt.Fprintf(buf, "func (q *Queries) %s(%s) ([]%s, error) {\n", q.def.Name, params, q.resultType)
+		fmt.Fprintf(buf, "\tvar dst []%s\n\terr := q.api.Select(ctx, db, &dst, %s)\n\treturn dst, err\n}\n\n", q.resultType, args)
+	case dbquery.QueryExec:
+		result := "pgconn.CommandTag"
+		if cfg.Driver == DriverSQL {
+			result = "sql.Result"
+		}
+		fmt.Fprintf(buf, "func (q *Queries) %s(%s) (%s, error) {\n", q.def.Name, params, result)
+		fmt.Fprintf(buf, "\treturn q.api.Exec(ctx, db, %s)\n}\n\n", args)
+	}
+}
+
+func quoteSQL(sql string) string {
+	if strings.ContainsRune(sql, '`') {
+		return strconv.Quote(sql)
+	}
+	return "`" + sql + "`"
+}
+
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	for i, r := range runes {
+		// Lower the leading run of capitals, so that "ID" becomes "id" and "GetUser" becomes "getUser".
+		if !unicode.IsUpper(r) || (i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			break
+		}
+		runes[i] = unicode.ToLower(r)
+	}
+	return string(runes)
+}
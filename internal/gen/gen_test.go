@@ -0,0 +1,137 @@
+package gen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/anton7r/orava/dbquery"
+	"github.com/anton7r/orava/internal/typemap"
+)
+
+const typesSrc = `package users
+
+type Card struct {
+	Issuer string
+}
+
+type UserParams struct {
+	ID   int64 ` + "`db:\"user_id\"`" + `
+	Card Card
+	Ref  *Card
+}
+
+type User struct {
+	ID   int64
+	Name string
+}
+`
+
+func testConfig(t *testing.T, sql string) Config {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "types.go", typesSrc, 0)
+	if err != nil {
+		t.Fatal("Errored while trying to parse source", err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("users", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal("Errored while trying to type check source", err)
+	}
+
+	defs, err := dbquery.ParseQueries("users.sql", sql)
+	if err != nil {
+		t.Fatal("Errored while trying to parse queries", err)
+	}
+
+	api, err := dbquery.NewAPI(dbquery.WithLexer(':', dbquery.SequentialDollarDelim))
+	if err != nil {
+		t.Fatal("Errored during api initialisation", err)
+	}
+
+	return Config{
+		Pkg:     pkg,
+		Driver:  DriverPgx,
+		Queries: defs,
+		API:     api,
+		Mapper:  typemap.DefaultMapper,
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	cfg := testConfig(t, `
+-- name: GetUserByID :one
+-- params: UserParams
+-- result: User
+SELECT id, name FROM users WHERE id = :user_id AND issuer = :card.issuer;
+
+-- name: ListUsers :many
+-- result: User
+SELECT id, name FROM users;
+
+-- name: DeleteUser :exec
+-- params: UserParams
+DELETE FROM users WHERE id = :user_id;
+`)
+
+	src, err := Generate(cfg)
+	if err != nil {
+		t.Fatal("Errored while trying to generate", err)
+	}
+
+	for _, expected := range []string{
+		"const getUserByIDSQL = `SELECT id, name FROM users WHERE id = $1 AND issuer = $2`",
+		"func (q *Queries) GetUserByID(ctx context.Context, db pgxquery.Querier, arg UserParams) (User, error) {",
+		"err := q.api.Get(ctx, db, &dst, getUserByIDSQL, arg.ID, arg.Card.Issuer)",
+		"func (q *Queries) ListUsers(ctx context.Context, db pgxquery.Querier) ([]User, error) {",
+		"func (q *Queries) DeleteUser(ctx context.Context, db pgxquery.Querier, arg UserParams) (pgconn.CommandTag, error) {",
+		"return q.api.Exec(ctx, db, deleteUserSQL, arg.ID)",
+	} {
+		if !strings.Contains(string(src), expected) {
+			t.Errorf("Expected generated code to contain: '%s', got:\n%s", expected, src)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	testCases := []struct {
+		sql            string
+		expectedErrStr string
+	}{
+		{
+			sql:            "-- name: GetUser :one\n-- params: UserParams\n-- result: User\nSELECT * FROM users WHERE id = :id",
+			expectedErrStr: "orava gen: users.sql:1: GetUser: field 'id' was not found from 'UserParams' struct",
+		},
+		{
+			sql:            "-- name: GetUser :one\n-- params: UserParams\n-- result: User\nSELECT * FROM users WHERE issuer = :ref.issuer",
+			expectedErrStr: "orava gen: users.sql:1: GetUser: field 'ref.issuer' of 'UserParams' is behind a pointer",
+		},
+		{
+			sql:            "-- name: GetUser :one\nSELECT * FROM users WHERE id = :user_id",
+			expectedErrStr: "orava gen: users.sql:1: GetUser: query has named params but no '-- params: <Type>' annotation",
+		},
+		{
+			sql:            "-- name: GetUser :one\nSELECT * FROM users",
+			expectedErrStr: "orava gen: users.sql:1: GetUser: :one query has no '-- result: <Type>' annotation",
+		},
+		{
+			sql:            "-- name: GetUser :many\n-- result: Missing\nSELECT * FROM users",
+			expectedErrStr: "orava gen: users.sql:1: GetUser: result type 'Missing' was not found",
+		},
+		{
+			sql:            "-- name: ListRows :many\n-- params: UserParams\n-- result: User\nSELECT * FROM {{table}}",
+			expectedErrStr: "orava gen: users.sql:1: ListRows: identifiers are not supported, found {{table}}",
+		},
+	}
+
+	for _, testCase := range testCases {
+		_, err := Generate(testConfig(t, testCase.sql))
+		if err == nil || err.Error() != testCase.expectedErrStr {
+			t.Errorf("Expected error: '%s', but got: '%v'", testCase.expectedErrStr, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package typemap maps the columns of Go struct types to their fields
+// the same way dbquery.API does, but working on go/types instead of reflect,
+// so that tools can resolve named params and destinations without running the code.
+package typemap
+
+import (
+	"go/types"
+	"reflect"
+	"strings"
+
+	"github.com/anton7r/orava/dbquery"
+)
+
+// Field is a struct field reachable from a column.
+type Field struct {
+	// Path is the chain of Go field names leading to the field, e.g. ["BankingInfo", "CreditCard", "Issuer"].
+	Path []string
+	Type types.Type
+	// ThroughPointer is true when one of the fields before the last one in Path is a pointer.
+	ThroughPointer bool
+	// InSlice is true when one of the fields before the last one in Path is a slice of structs,
+	// such fields are only filled when scanning aggregated rows.
+	InSlice bool
+}
+
+// Selector returns the field path joined with dots.
+func (f Field) Selector() string {
+	return strings.Join(f.Path, ".")
+}
+
+// Mapper holds the configuration used for mapping columns to fields.
+type Mapper struct {
+	TagKey          string
+	ColumnSeparator string
+	FieldMapperFn   dbquery.NameMapperFunc
+}
+
+// DefaultMapper has the same configuration as dbquery.NewAPI without options.
+var DefaultMapper = Mapper{
+	TagKey:          "db",
+	ColumnSeparator: ".",
+	FieldMapperFn:   dbquery.SnakeCaseMapper,
+}
+
+type toTraverse struct {
+	st             *types.Struct
+	pathPrefix     []string
+	columnPrefix   string
+	throughPointer bool
+	inSlice        bool
+}
+
+// StructOf returns the struct type behind named types and pointers,
+// or nil if t is not a struct.
+func StructOf(t types.Type) *types.Struct {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	st, _ := t.Underlying().(*types.Struct)
+	return st
+}
+
+// Columns returns the columns of the struct type and the fields they are mapped to.
+// It mirrors the traversal of dbquery's column to field index map, so the first field
+// found for a column in breadth first order wins.
+// The columns of the elements of struct slices are included as well and marked with InSlice.
+func (m Mapper) Columns(st *types.Struct) map[string]Field {
+	result := map[string]Field{}
+	queue := []toTraverse{{st: st}}
+	for len(queue) > 0 {
+		traversal := queue[0]
+		queue = queue[1:]
+		for i := 0; i < traversal.st.NumFields(); i++ {
+			field := traversal.st.Field(i)
+			if !field.Exported() && !field.Embedded() {
+				// Field is unexported, skip it.
+				continue
+			}
+			dbTag, dbTagPresent := reflect.StructTag(traversal.st.Tag(i)).Lookup(m.TagKey)
+			if dbTagPresent {
+				dbTag = strings.Split(dbTag, ",")[0]
+			}
+			if dbTag == "-" {
+				// Field is ignored, skip it.
+				continue
+			}
+			path := make([]string, 0, len(traversal.pathPrefix)+1)
+			path = append(path, traversal.pathPrefix...)
+			path = append(path, field.Name())
+			columnPart := dbTag
+			if !dbTagPresent {
+				columnPart = m.FieldMapperFn(field.Name())
+			}
+			if !field.Embedded() {
+				column := m.buildColumn(traversal.columnPrefix, columnPart)
+				if _, exists := result[column]; !exists {
+					result[column] = Field{
+						Path:           path,
+						Type:           field.Type(),
+						ThroughPointer: traversal.throughPointer,
+						InSlice:        traversal.inSlice,
+					}
+				}
+			}
+			_, isPointer := field.Type().Underlying().(*types.Pointer)
+			if childStruct := StructOf(field.Type()); childStruct != nil {
+				if field.Embedded() {
+					columnPart = dbTag
+				}
+				queue = append(queue, toTraverse{
+					st:             childStruct,
+					pathPrefix:     path,
+					columnPrefix:   m.buildColumn(traversal.columnPrefix, columnPart),
+					throughPointer: traversal.throughPointer || isPointer,
+					inSlice:        traversal.inSlice,
+				})
+			} else if slice, ok := field.Type().Underlying().(*types.Slice); ok && !field.Embedded() {
+				if elemStruct := StructOf(slice.Elem()); elemStruct != nil {
+					queue = append(queue, toTraverse{
+						st:             elemStruct,
+						pathPrefix:     path,
+						columnPrefix:   m.buildColumn(traversal.columnPrefix, columnPart),
+						throughPointer: traversal.throughPointer,
+						inSlice:        true,
+					})
+				}
+			}
+		}
+	}
+	return result
+}
+
+func (m Mapper) buildColumn(parts ...string) string {
+	var notEmptyParts []string
+	for _, p := range parts {
+		if p != "" {
+			notEmptyParts = append(notEmptyParts, p)
+		}
+	}
+	return strings.Join(notEmptyParts, m.ColumnSeparator)
+}
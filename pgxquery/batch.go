@@ -0,0 +1,111 @@
+package pgxquery
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// BatchQuerier is a Querier that can also send batches of queries.
+// For example, it can be: *pgxpool.Pool, *pgx.Conn or pgx.Tx.
+type BatchQuerier interface {
+	Querier
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+var (
+	_ BatchQuerier = &pgxpool.Pool{}
+	_ BatchQuerier = &pgx.Conn{}
+	_ BatchQuerier = pgx.Tx(nil)
+)
+
+// Batch queues queries that are sent to the database in a single round trip.
+type Batch struct {
+	api   *API
+	batch *pgx.Batch
+}
+
+// NewBatch returns a new empty Batch.
+func (api *API) NewBatch() *Batch {
+	return &Batch{api: api, batch: &pgx.Batch{}}
+}
+
+// Queue queues the query with the positional args.
+func (b *Batch) Queue(query string, args ...interface{}) {
+	b.batch.Queue(query, args...)
+}
+
+// QueueNamed compiles the named query and queues it with the args from arg.
+func (b *Batch) QueueNamed(query string, arg interface{}) error {
+	compiledQuery, args, err := b.api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return err
+	}
+
+	b.batch.Queue(compiledQuery, args...)
+	return nil
+}
+
+// QueuePrepared queues the prepared named query with the args from arg.
+func (b *Batch) QueuePrepared(pq *PreparedQuery, arg interface{}) error {
+	query, args, err := pq.prep.GetQuery(arg)
+	if err != nil {
+		return err
+	}
+
+	b.batch.Queue(query, args...)
+	return nil
+}
+
+// Len returns the number of queued queries.
+func (b *Batch) Len() int {
+	return b.batch.Len()
+}
+
+// Send sends the queued queries to the database.
+// The results must be read in the queue order and closed with BatchResults.Close.
+func (b *Batch) Send(ctx context.Context, db BatchQuerier) *BatchResults {
+	return &BatchResults{api: b.api, results: db.SendBatch(ctx, b.batch)}
+}
+
+// BatchResults reads the results of the queries of a Batch in the queue order.
+type BatchResults struct {
+	api     *API
+	results pgx.BatchResults
+}
+
+// Select reads the rows of the next query into the destination slice.
+// See ScanAll for details.
+func (br *BatchResults) Select(dst interface{}) error {
+	rows, err := br.results.Query()
+	if err != nil {
+		return errors.Wrap(err, "orava: query multiple result rows of batch")
+	}
+	err = br.api.ScanAll(dst, rows)
+	return errors.WithStack(err)
+}
+
+// Get reads the single row of the next query into the destination.
+// See ScanOne for details.
+func (br *BatchResults) Get(dst interface{}) error {
+	rows, err := br.results.Query()
+	if err != nil {
+		return errors.Wrap(err, "orava: query one result row of batch")
+	}
+	err = br.api.ScanOne(dst, rows)
+	return errors.WithStack(err)
+}
+
+// Exec reads the command tag of the next query.
+func (br *BatchResults) Exec() (pgconn.CommandTag, error) {
+	tag, err := br.results.Exec()
+	return tag, errors.Wrap(err, "orava: exec batch query")
+}
+
+// Close closes the results, reading the results of the queries that were not read.
+func (br *BatchResults) Close() error {
+	return errors.WithStack(br.results.Close())
+}
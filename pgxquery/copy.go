@@ -0,0 +1,149 @@
+package pgxquery
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// CopyFromQuerier is something that can bulk load rows with the COPY protocol.
+// For example, it can be: *pgxpool.Pool, *pgx.Conn or pgx.Tx.
+type CopyFromQuerier interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+var (
+	_ CopyFromQuerier = &pgxpool.Pool{}
+	_ CopyFromQuerier = &pgx.Conn{}
+	_ CopyFromQuerier = pgx.Tx(nil)
+)
+
+// CopyStructs bulk loads the structs of src into the table with the COPY protocol and returns the number of copied rows.
+// The src can be a slice or a channel of structs by value or by a pointer, the channel is read until it's closed.
+// The columns are the ones the fields of the struct are mapped to, the fields of embedded structs are columns of their own,
+// and the fields of nil embedded struct pointers are copied as NULL. Named nested structs can't be copied.
+// The table can be qualified with the schema, e.g. pgx.Identifier{"public", "users"}.
+// Reading the channel stops with the error of ctx when it's done.
+func (api *API) CopyStructs(ctx context.Context, db CopyFromQuerier, table pgx.Identifier, src interface{}) (int64, error) {
+	source, err := api.newStructCopySource(ctx, src)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := db.CopyFrom(ctx, table, source.columns, source)
+	return count, errors.Wrap(err, "orava: copy structs")
+}
+
+// structCopySource implements pgx.CopyFromSource reading the values of the fields of the structs,
+// the values are read into the same buffer for every row.
+type structCopySource struct {
+	api          *API
+	next         func() (reflect.Value, bool)
+	columns      []string
+	fieldIndexes [][]int
+	current      reflect.Value
+	values       []interface{}
+	err          error
+}
+
+func (api *API) newStructCopySource(ctx context.Context, src interface{}) (*structCopySource, error) {
+	srcVal := reflect.ValueOf(src)
+	if !srcVal.IsValid() {
+		return nil, errors.New("orava: copy source must be a slice or a channel of structs, got: nil")
+	}
+
+	source := &structCopySource{api: api}
+	switch srcVal.Kind() {
+	case reflect.Slice:
+		i := 0
+		source.next = func() (reflect.Value, bool) {
+			if i >= srcVal.Len() {
+				return reflect.Value{}, false
+			}
+			i++
+			return srcVal.Index(i - 1), true
+		}
+	case reflect.Chan:
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: srcVal},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		}
+		source.next = func() (reflect.Value, bool) {
+			chosen, value, ok := reflect.Select(cases)
+			if chosen == 1 {
+				source.err = errors.Wrap(ctx.Err(), "orava: read copy source")
+				return reflect.Value{}, false
+			}
+			return value, ok
+		}
+	default:
+		return nil, errors.Errorf("orava: copy source must be a slice or a channel of structs, got: %v", srcVal.Type())
+	}
+
+	structType := srcVal.Type().Elem()
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return nil, errors.Errorf("orava: copy source must be a slice or a channel of structs, got: %v", srcVal.Type())
+	}
+
+	source.columns, source.fieldIndexes = api.dbqueryAPI.LeafColumns(structType)
+	if len(source.columns) == 0 {
+		return nil, errors.Errorf("orava: %v has no fields to copy", structType)
+	}
+	for _, column := range source.columns {
+		// The columns of nested structs, like address.city, have no column of their own in the table.
+		if strings.Contains(column, api.dbqueryAPI.ColumnSeparator()) {
+			return nil, errors.Errorf("orava: column '%s' of %v is a field of a nested struct, which can't be copied to a table column, embed the struct instead", column, structType)
+		}
+	}
+	source.values = make([]interface{}, len(source.columns))
+	return source, nil
+}
+
+// Next implements pgx.CopyFromSource.
+func (s *structCopySource) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	value, ok := s.next()
+	if !ok {
+		return false
+	}
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			s.err = errors.New("orava: copy source contains a nil struct")
+			return false
+		}
+		value = value.Elem()
+	}
+	s.current = value
+	return true
+}
+
+// Values implements pgx.CopyFromSource.
+func (s *structCopySource) Values() ([]interface{}, error) {
+	for i, fieldIndex := range s.fieldIndexes {
+		field, err := s.current.FieldByIndexErr(fieldIndex)
+		if err != nil {
+			// The field is behind a nil struct pointer.
+			s.values[i] = nil
+			continue
+		}
+		s.values[i], err = s.api.dbqueryAPI.BindValue(field.Interface())
+		if err != nil {
+			return nil, errors.Wrapf(err, "orava: bind value of column '%s'", s.columns[i])
+		}
+	}
+	return s.values, nil
+}
+
+// Err implements pgx.CopyFromSource.
+func (s *structCopySource) Err() error {
+	return s.err
+}
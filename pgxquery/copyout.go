@@ -0,0 +1,461 @@
+package pgxquery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// CopyOutOption configures the decoding of the rows of CopyOut, CopyOutEach and CopyOutJSON.
+type CopyOutOption func(o *copyOutOptions)
+
+type copyOutOptions struct {
+	binary bool
+}
+
+// WithCopyOutBinary streams the rows in the binary format of COPY and decodes them with the binary scan plans
+// of the type map, which saves parsing the text of numbers, timestamps and the like.
+// The text format is used when any of the column types has no binary codec in the type map,
+// the server must support COPY TO in the binary format.
+func WithCopyOutBinary() CopyOutOption {
+	return func(o *copyOutOptions) {
+		o.binary = true
+	}
+}
+
+// CopyOut exports the rows of the query with COPY (query) TO STDOUT into the destination slice,
+// which is scanned the same way as with Select. See ScanAll for details.
+// The rows are streamed in the text format, or the binary one with WithCopyOutBinary,
+// and decoded with the type map of the connection as they arrive.
+// The query can't have params, as COPY doesn't support them.
+// The db must be a *pgxpool.Pool, *pgxpool.Conn, *pgx.Conn or pgx.Tx.
+// A COPY can't be interrupted without closing the connection, so when scanning fails
+// the rest of the rows are read and discarded, keeping the connection, e.g. of a pgx.Tx, usable.
+// Canceling ctx does interrupt it and closes the connection.
+func (api *API) CopyOut(ctx context.Context, db Querier, dst interface{}, query string, opts ...CopyOutOption) error {
+	return api.copyOutRows(ctx, db, query, opts, func(rows *copyRows) error {
+		return errors.WithStack(api.dbqueryAPI.ScanAll(dst, rows))
+	})
+}
+
+// CopyOutEach exports the rows of the query with COPY (query) TO STDOUT calling fn with each row scanned into T.
+// Returning an error from fn stops calling it and the rest of the rows are read and discarded before returning the error.
+// See API.CopyOut for details.
+func CopyOutEach[T any](ctx context.Context, api *API, db Querier, query string, fn func(T) error, opts ...CopyOutOption) error {
+	return api.copyOutRows(ctx, db, query, opts, func(rows *copyRows) error {
+		rs := api.dbqueryAPI.NewRowScanner(rows)
+		for rows.Next() {
+			var value T
+			if err := rs.Scan(&value); err != nil {
+				return errors.WithStack(err)
+			}
+			if err := fn(value); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+}
+
+// CopyOutCSV writes the rows of the query to w as CSV with a header line, as COPY formats them,
+// and returns the number of rows.
+// When writing to w fails the rest of the rows are discarded before returning the error, see API.CopyOut.
+func (api *API) CopyOutCSV(ctx context.Context, db Querier, w io.Writer, query string) (int64, error) {
+	var count int64
+	err := withPgConn(ctx, db, func(conn *pgx.Conn) error {
+		dw := &discardingWriter{w: w}
+		tag, err := conn.PgConn().CopyTo(ctx, dw, "COPY ("+query+") TO STDOUT WITH (FORMAT csv, HEADER true)")
+		count = tag.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "orava: copy out")
+		}
+		return errors.Wrap(dw.err, "orava: write csv")
+	})
+	return count, err
+}
+
+// discardingWriter discards everything written after the first error of w,
+// so that CopyTo reads the copy to the end instead of closing the connection.
+type discardingWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (dw *discardingWriter) Write(p []byte) (int, error) {
+	if dw.err == nil {
+		_, dw.err = dw.w.Write(p)
+	}
+	return len(p), nil
+}
+
+// CopyOutJSON writes the rows of the query to w as JSON lines, one object per row with the keys in the column order,
+// and returns the number of rows. The values are decoded into the default Go types of the column types.
+// See API.CopyOut for the options and for what happens when writing to w fails.
+func (api *API) CopyOutJSON(ctx context.Context, db Querier, w io.Writer, query string, opts ...CopyOutOption) (int64, error) {
+	var count int64
+	err := api.copyOutRows(ctx, db, query, opts, func(rows *copyRows) error {
+		keys := make([][]byte, len(rows.columns))
+		for i, column := range rows.columns {
+			key, err := json.Marshal(column)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			keys[i] = key
+		}
+
+		values := make([]interface{}, len(rows.columns))
+		scans := make([]interface{}, len(rows.columns))
+		for i := range values {
+			scans[i] = &values[i]
+		}
+		line := &bytes.Buffer{}
+		for rows.Next() {
+			if err := rows.Scan(scans...); err != nil {
+				return err
+			}
+			line.Reset()
+			line.WriteByte('{')
+			for i, value := range values {
+				if i > 0 {
+					line.WriteByte(',')
+				}
+				line.Write(keys[i])
+				line.WriteByte(':')
+				encoded, err := json.Marshal(value)
+				if err != nil {
+					return errors.Wrapf(err, "orava: encode column '%s'", rows.columns[i])
+				}
+				line.Write(encoded)
+			}
+			line.WriteString("}\n")
+			if _, err := w.Write(line.Bytes()); err != nil {
+				return errors.Wrap(err, "orava: write json line")
+			}
+			count++
+		}
+		return rows.Err()
+	})
+	return count, err
+}
+
+// withPgConn calls fn with the connection of db, acquiring it from the pool if needed.
+func withPgConn(ctx context.Context, db Querier, fn func(conn *pgx.Conn) error) error {
+	conn, release, err := acquireConn(ctx, db)
+	if err != nil {
+		return err
+	}
+	if conn == nil {
+		return errors.Errorf("orava: %T doesn't give access to its connection", db)
+	}
+	defer release()
+	return fn(conn)
+}
+
+// acquireConn returns the connection of db, acquiring it from the pool if needed,
+// and the function releasing it, which must be called when the connection is no longer used.
+// The connection is nil when db doesn't give access to its connection.
+func acquireConn(ctx context.Context, db Querier) (*pgx.Conn, func(), error) {
+	noop := func() {}
+	switch db := db.(type) {
+	case *pgxpool.Pool:
+		pooled, err := db.Acquire(ctx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "orava: acquire connection")
+		}
+		return pooled.Conn(), pooled.Release, nil
+	case *pgxpool.Conn:
+		return db.Conn(), noop, nil
+	case *pgx.Conn:
+		return db, noop, nil
+	case pgx.Tx:
+		return db.Conn(), noop, nil
+	}
+	return nil, noop, nil
+}
+
+// copyOutRows streams the rows of COPY (query) TO STDOUT to consume.
+func (api *API) copyOutRows(ctx context.Context, db Querier, query string, opts []CopyOutOption, consume func(rows *copyRows) error) error {
+	options := copyOutOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return withPgConn(ctx, db, func(conn *pgx.Conn) error {
+		// COPY doesn't describe its rows, so the columns and their types are described by preparing the query.
+		sd, err := conn.PgConn().Prepare(ctx, "", query, nil)
+		if err != nil {
+			return errors.Wrap(err, "orava: describe copy out query")
+		}
+
+		format := int16(pgtype.TextFormatCode)
+		statement := "COPY (" + query + ") TO STDOUT"
+		if options.binary && binaryCodecs(conn.TypeMap(), sd.Fields) {
+			format = pgtype.BinaryFormatCode
+			statement += " WITH (FORMAT binary)"
+		}
+
+		reader, writer := io.Pipe()
+		done := make(chan error, 1)
+		go func() {
+			_, err := conn.PgConn().CopyTo(ctx, writer, statement)
+			writer.CloseWithError(err)
+			done <- err
+		}()
+
+		rows := newCopyRows(reader, sd.Fields, conn.TypeMap(), format)
+		consumeErr := consume(rows)
+		// Failing the writes of CopyTo would close the connection,
+		// so the rows that were not consumed are read to the end of the copy instead.
+		_, _ = io.Copy(io.Discard, reader)
+		reader.Close()
+		copyErr := <-done
+
+		if consumeErr != nil {
+			return consumeErr
+		}
+		return errors.Wrap(copyErr, "orava: copy out")
+	})
+}
+
+// binaryCodecs reports whether all of the column types can be decoded from the binary format.
+func binaryCodecs(typeMap *pgtype.Map, fields []pgconn.FieldDescription) bool {
+	for _, fd := range fields {
+		t, ok := typeMap.TypeForOID(fd.DataTypeOID)
+		if !ok || !t.Codec.FormatSupported(pgtype.BinaryFormatCode) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyBinarySignature starts the header of the binary format of COPY.
+var copyBinarySignature = []byte("PGCOPY\n\377\r\n\000")
+
+// copyRows implements dbquery.Rows reading the rows of COPY in the text or the binary format.
+type copyRows struct {
+	reader     *bufio.Reader
+	columns    []string
+	fields     []pgconn.FieldDescription
+	typeMap    *pgtype.Map
+	format     int16
+	headerRead bool
+	plans      []pgtype.ScanPlan
+	types      []reflect.Type
+	record     [][]byte
+	lengths    []int
+	buf        []byte
+	err        error
+}
+
+func newCopyRows(r io.Reader, fields []pgconn.FieldDescription, typeMap *pgtype.Map, format int16) *copyRows {
+	columns := make([]string, len(fields))
+	for i, fd := range fields {
+		columns[i] = fd.Name
+	}
+	return &copyRows{
+		reader:  bufio.NewReaderSize(r, 64*1024),
+		columns: columns,
+		fields:  fields,
+		typeMap: typeMap,
+		format:  format,
+		plans:   make([]pgtype.ScanPlan, len(fields)),
+		types:   make([]reflect.Type, len(fields)),
+		record:  make([][]byte, len(fields)),
+		lengths: make([]int, len(fields)),
+	}
+}
+
+func (r *copyRows) Columns() ([]string, error) {
+	return r.columns, nil
+}
+
+func (r *copyRows) Close() error {
+	return nil
+}
+
+func (r *copyRows) Err() error {
+	return r.err
+}
+
+func (r *copyRows) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	if r.format == pgtype.BinaryFormatCode {
+		return r.nextBinary()
+	}
+	line, err := r.reader.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		// Rows longer than the buffer are collected into a new slice.
+		long := append([]byte(nil), line...)
+		for err == bufio.ErrBufferFull {
+			line, err = r.reader.ReadSlice('\n')
+			long = append(long, line...)
+		}
+		line = long
+	}
+	if err == io.EOF && len(line) == 0 {
+		return false
+	}
+	if err != nil && err != io.EOF {
+		r.err = errors.Wrap(err, "orava: read copy out row")
+		return false
+	}
+	if err := r.parse(bytes.TrimSuffix(line, []byte{'\n'})); err != nil {
+		r.err = err
+		return false
+	}
+	return true
+}
+
+// parse splits the line into the values of the columns, NULLs are left nil.
+func (r *copyRows) parse(line []byte) error {
+	values := bytes.Split(line, []byte{'\t'})
+	if len(values) != len(r.columns) {
+		return errors.Errorf("orava: copy out row has %d values, expected %d", len(values), len(r.columns))
+	}
+	for i, value := range values {
+		if len(value) == 2 && value[0] == '\\' && value[1] == 'N' {
+			r.record[i] = nil
+			continue
+		}
+		r.record[i] = unescapeCopyText(value)
+	}
+	return nil
+}
+
+// nextBinary reads the next row of the binary format, in which each row is the number of its values
+// followed by each value as its length and its bytes, a length of -1 being NULL.
+func (r *copyRows) nextBinary() bool {
+	if !r.headerRead {
+		if err := r.readBinaryHeader(); err != nil {
+			r.err = err
+			return false
+		}
+		r.headerRead = true
+	}
+
+	var header [4]byte
+	if _, err := io.ReadFull(r.reader, header[:2]); err != nil {
+		r.err = errors.Wrap(err, "orava: read copy out row")
+		return false
+	}
+	count := int16(binary.BigEndian.Uint16(header[:2]))
+	if count == -1 {
+		// The trailer ends the rows.
+		return false
+	}
+	if int(count) != len(r.columns) {
+		r.err = errors.Errorf("orava: copy out row has %d values, expected %d", count, len(r.columns))
+		return false
+	}
+
+	r.buf = r.buf[:0]
+	for i := range r.lengths {
+		if _, err := io.ReadFull(r.reader, header[:]); err != nil {
+			r.err = errors.Wrap(err, "orava: read copy out row")
+			return false
+		}
+		length := int(int32(binary.BigEndian.Uint32(header[:])))
+		r.lengths[i] = length
+		if length < 0 {
+			continue
+		}
+		start := len(r.buf)
+		r.buf = append(r.buf, make([]byte, length)...)
+		if _, err := io.ReadFull(r.reader, r.buf[start:]); err != nil {
+			r.err = errors.Wrap(err, "orava: read copy out row")
+			return false
+		}
+	}
+
+	// The values are sliced once the whole row is read, as appending to the buffer may move it.
+	offset := 0
+	for i, length := range r.lengths {
+		if length < 0 {
+			r.record[i] = nil
+			continue
+		}
+		r.record[i] = r.buf[offset : offset+length : offset+length]
+		offset += length
+	}
+	return true
+}
+
+// readBinaryHeader checks the signature of the binary format and skips the rest of its header.
+func (r *copyRows) readBinaryHeader() error {
+	header := make([]byte, len(copyBinarySignature)+8)
+	if _, err := io.ReadFull(r.reader, header); err != nil {
+		return errors.Wrap(err, "orava: read copy out header")
+	}
+	if !bytes.Equal(header[:len(copyBinarySignature)], copyBinarySignature) {
+		return errors.New("orava: copy out header has an invalid signature")
+	}
+	extension := binary.BigEndian.Uint32(header[len(copyBinarySignature)+4:])
+	if _, err := r.reader.Discard(int(extension)); err != nil {
+		return errors.Wrap(err, "orava: read copy out header")
+	}
+	return nil
+}
+
+// unescapeCopyText decodes the backslash escapes of the COPY text format.
+func unescapeCopyText(value []byte) []byte {
+	if bytes.IndexByte(value, '\\') < 0 {
+		return value
+	}
+	unescaped := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			unescaped = append(unescaped, value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'b':
+			unescaped = append(unescaped, '\b')
+		case 'f':
+			unescaped = append(unescaped, '\f')
+		case 'n':
+			unescaped = append(unescaped, '\n')
+		case 'r':
+			unescaped = append(unescaped, '\r')
+		case 't':
+			unescaped = append(unescaped, '\t')
+		case 'v':
+			unescaped = append(unescaped, '\v')
+		default:
+			unescaped = append(unescaped, value[i])
+		}
+	}
+	return unescaped
+}
+
+func (r *copyRows) Scan(dest ...interface{}) error {
+	if len(dest) != len(r.record) {
+		return errors.Errorf("orava: number of field descriptions must equal number of destinations, got %d and %d", len(r.record), len(dest))
+	}
+	for i, target := range dest {
+		if target == nil {
+			continue
+		}
+		targetType := reflect.TypeOf(target)
+		if r.plans[i] == nil || r.types[i] != targetType {
+			r.plans[i] = r.typeMap.PlanScan(r.fields[i].DataTypeOID, r.format, target)
+			r.types[i] = targetType
+		}
+		if err := r.plans[i].Scan(r.record[i], target); err != nil {
+			return errors.Wrapf(err, "orava: scan column '%s'", r.columns[i])
+		}
+	}
+	return nil
+}
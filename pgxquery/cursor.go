@@ -0,0 +1,121 @@
+package pgxquery
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+var cursorSeq uint64
+
+// Cursor fetches the rows of a query in chunks from a server-side cursor, see API.Cursor.
+type Cursor struct {
+	api       *API
+	tx        pgx.Tx
+	dst       interface{}
+	name      string
+	fetchSize int
+	done      bool
+	closed    bool
+	err       error
+}
+
+// Cursor declares a server-side cursor for the query in the transaction, which Next fetches fetchSize rows at a time.
+// Each call to Next scans the next chunk into dst, which must be a pointer to a slice, replacing the previous chunk,
+// so tables larger than the memory can be processed a chunk at a time.
+// The elements can't be structs aggregated by their primary key, their rows could span the chunks.
+// The cursor is closed when the rows run out or fetching them fails, and Close closes it before that.
+func (api *API) Cursor(ctx context.Context, tx pgx.Tx, dst interface{}, query string, args []interface{}, fetchSize int) (*Cursor, error) {
+	dstType := reflect.TypeOf(dst)
+	if dstType == nil || dstType.Kind() != reflect.Ptr || dstType.Elem().Kind() != reflect.Slice {
+		return nil, errors.Errorf("orava: cursor destination must be a pointer to a slice, got: %T", dst)
+	}
+	elemType := dstType.Elem().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if api.dbqueryAPI.Aggregated(elemType) {
+		return nil, errors.Errorf("orava: cursor destination can't aggregate rows by primary key, got: %T", dst)
+	}
+	if fetchSize <= 0 {
+		return nil, errors.Errorf("orava: cursor fetch size must be positive, got: %d", fetchSize)
+	}
+
+	name := "orava_cursor_" + strconv.FormatUint(atomic.AddUint64(&cursorSeq, 1), 10)
+	ctx = withDestination(ctx, dst)
+	if _, err := tx.Exec(ctx, "DECLARE "+pgx.Identifier{name}.Sanitize()+" NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return nil, errors.Wrap(err, "orava: declare cursor")
+	}
+
+	return &Cursor{api: api, tx: tx, dst: dst, name: name, fetchSize: fetchSize}, nil
+}
+
+// countingRows counts the rows read from pgx.Rows.
+type countingRows struct {
+	pgx.Rows
+	count int
+}
+
+func (r *countingRows) Next() bool {
+	if !r.Rows.Next() {
+		return false
+	}
+	r.count++
+	return true
+}
+
+// Next fetches the next chunk of rows into the destination of the cursor.
+// It returns false when there are no more rows or fetching them failed, see Err.
+func (c *Cursor) Next(ctx context.Context) bool {
+	if c.done || c.err != nil {
+		return false
+	}
+
+	ctx = withDestination(ctx, c.dst)
+	rows, err := c.tx.Query(ctx, "FETCH "+strconv.Itoa(c.fetchSize)+" FROM "+pgx.Identifier{c.name}.Sanitize())
+	if err != nil {
+		c.fail(ctx, errors.Wrap(err, "orava: fetch from cursor"))
+		return false
+	}
+	counted := &countingRows{Rows: rows}
+	if err := c.api.ScanAll(c.dst, c.api.withQueryContext(ctx, counted)); err != nil {
+		c.fail(ctx, err)
+		return false
+	}
+
+	if counted.count == 0 {
+		// The rows have run out.
+		c.done = true
+		if err := c.Close(ctx); err != nil {
+			c.err = err
+		}
+		return false
+	}
+	return true
+}
+
+// Err returns the error that stopped Next, if any.
+func (c *Cursor) Err() error {
+	return c.err
+}
+
+// Close closes the cursor, it does nothing when the cursor has already been closed.
+func (c *Cursor) Close(ctx context.Context) error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.done = true
+	_, err := c.tx.Exec(ctx, "CLOSE "+pgx.Identifier{c.name}.Sanitize())
+	return errors.Wrap(err, "orava: close cursor")
+}
+
+func (c *Cursor) fail(ctx context.Context, err error) {
+	c.err = err
+	// The transaction is likely aborted by the failure, so the error of closing the cursor adds nothing.
+	_ = c.Close(ctx)
+}
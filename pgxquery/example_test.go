@@ -2,7 +2,7 @@ package pgxquery_test
 
 import "github.com/jackc/pgx/v5/pgxpool"
 
-func ExampleSelectNamed() {
+func ExampleAPI_SelectNamed() {
 	type User struct {
 		ID       string `db:"user_id"`
 		FullName string
@@ -20,14 +20,14 @@ func ExampleSelectNamed() {
 
 	var users []*User
 	if err := api.SelectNamed(
-		ctx, db, &users, `SELECT user_id, full_name, email, age FROM :name`, &Table{Name: "users"},
+		ctx, db, &users, `SELECT user_id, full_name, email, age FROM {{name}}`, &Table{Name: "users"},
 	); err != nil {
 		// Handle query or rows processing error.
 	}
 	// users variable now contains data from all rows.
 }
 
-func ExampleGetNamed() {
+func ExampleAPI_GetNamed() {
 	type User struct {
 		ID       string `db:"user_id"`
 		FullName string
@@ -48,7 +48,7 @@ func ExampleGetNamed() {
 	// user variable now contains data from all rows.
 }
 
-func ExampleExecNamed() {
+func ExampleAPI_ExecNamed() {
 	type User struct {
 		ID       string `db:"user_id"`
 		FullName string
@@ -76,7 +76,7 @@ func ExampleExecNamed() {
 
 	// let us now delete it
 	if _, err := api.ExecNamed(
-		ctx, db, `DELETE FROM users WHERE user_id = :ID`, user,
+		ctx, db, `DELETE FROM users WHERE user_id = :user_id`, user,
 	); err != nil {
 		// Handle exec processing error.
 	}
@@ -0,0 +1,12 @@
+package pgxquery
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Dispatch exposes the delivery of a notification to the handlers for the tests.
+func (l *Listener) Dispatch(ctx context.Context, n *pgconn.Notification) {
+	l.dispatch(ctx, n)
+}
@@ -0,0 +1,189 @@
+package pgxquery
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// Listener delivers the notifications of the channels it listens to, to the handlers of the channels.
+// It listens on a dedicated connection taken from the pool, which is replaced when it drops.
+type Listener struct {
+	pool       *pgxpool.Pool
+	mu         sync.RWMutex
+	handlers   map[string]func(ctx context.Context, n *pgconn.Notification) error
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	onError    func(err error)
+}
+
+// ListenerOption configures the Listener created with NewListener.
+type ListenerOption func(l *Listener)
+
+// WithListenBackoff sets the delays between the attempts to reconnect, which start from min and double up to max.
+// The defaults are 100ms and 30s.
+func WithListenBackoff(min, max time.Duration) ListenerOption {
+	return func(l *Listener) {
+		l.minBackoff = min
+		l.maxBackoff = max
+	}
+}
+
+// WithListenErrorHandler sets the function that is called with the errors that don't stop the Listener,
+// like dropped connections and the errors returned by the handlers.
+func WithListenErrorHandler(onError func(err error)) ListenerOption {
+	return func(l *Listener) {
+		l.onError = onError
+	}
+}
+
+// NewListener returns a new Listener that takes its connection from the pool.
+func (api *API) NewListener(pool *pgxpool.Pool, opts ...ListenerOption) *Listener {
+	l := &Listener{
+		pool:       pool,
+		handlers:   map[string]func(ctx context.Context, n *pgconn.Notification) error{},
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+		onError:    func(err error) {},
+	}
+
+	for _, o := range opts {
+		o(l)
+	}
+
+	return l
+}
+
+// HandleRaw sets the handler of the notifications of the channel, which gets the payloads as they were sent,
+// e.g. with NotifyRaw. The handlers must be set before calling Listen,
+// the channels of the handlers set later are only listened to after reconnecting.
+func (l *Listener) HandleRaw(channel string, handler func(ctx context.Context, n *pgconn.Notification) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handlers[channel] = handler
+}
+
+// Handle sets the handler of the notifications of the channel, which payloads are decoded from JSON into T,
+// like the payloads sent with Notify. The handlers must be set before calling Listen.
+func Handle[T any](l *Listener, channel string, handler func(ctx context.Context, payload T) error) {
+	l.HandleRaw(channel, func(ctx context.Context, n *pgconn.Notification) error {
+		var payload T
+		if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
+			return errors.Wrapf(err, "orava: decode payload of channel '%s'", channel)
+		}
+		return handler(ctx, payload)
+	})
+}
+
+// Subscribe returns a channel that receives the notifications of the channel, which payloads are decoded from JSON into T.
+// The Listener waits for the receiver when the buffer of the channel is full,
+// and the channel is never closed. It must be called before calling Listen.
+func Subscribe[T any](l *Listener, channel string, buffer int) <-chan T {
+	ch := make(chan T, buffer)
+	Handle(l, channel, func(ctx context.Context, payload T) error {
+		select {
+		case ch <- payload:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	return ch
+}
+
+// Listen listens to the channels of the handlers and delivers their notifications until the context is done,
+// reconnecting with backoff when the connection drops. It returns the error of the context.
+func (l *Listener) Listen(ctx context.Context) error {
+	backoff := l.minBackoff
+	for {
+		listening, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		l.onError(err)
+		if listening {
+			// The connection worked before dropping, so the next attempt starts over.
+			backoff = l.minBackoff
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+		if backoff > l.maxBackoff {
+			backoff = l.maxBackoff
+		}
+	}
+}
+
+// listen listens on a new connection until it fails, and reports whether the channels were listened to.
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	pooled, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "orava: acquire listener connection")
+	}
+	// The connection listens to the channels, so it can't be returned to the pool.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background()) // nolint: errcheck
+
+	for _, channel := range l.channels() {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return false, errors.Wrapf(err, "orava: listen to channel '%s'", channel)
+		}
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, errors.Wrap(err, "orava: wait for notification")
+		}
+		l.dispatch(ctx, n)
+	}
+}
+
+// channels returns the channels of the handlers.
+func (l *Listener) channels() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	channels := make([]string, 0, len(l.handlers))
+	for channel := range l.handlers {
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+// dispatch delivers the notification to the handler of its channel, reporting the error of the handler.
+func (l *Listener) dispatch(ctx context.Context, n *pgconn.Notification) {
+	l.mu.RLock()
+	handler, ok := l.handlers[n.Channel]
+	l.mu.RUnlock()
+	if !ok {
+		return
+	}
+	if err := handler(ctx, n); err != nil {
+		l.onError(err)
+	}
+}
+
+// Notify sends a notification to the channel with the payload encoded as JSON, to be decoded by Handle or Subscribe.
+func (api *API) Notify(ctx context.Context, db Querier, channel string, payload interface{}) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrapf(err, "orava: encode payload of channel '%s'", channel)
+	}
+	return api.NotifyRaw(ctx, db, channel, string(encoded))
+}
+
+// NotifyRaw sends a notification to the channel with the payload as it is, to be handled by HandleRaw.
+func (api *API) NotifyRaw(ctx context.Context, db Querier, channel string, payload string) error {
+	_, err := db.Exec(ctx, "SELECT pg_notify($1, $2)", channel, payload)
+	return errors.Wrapf(err, "orava: notify channel '%s'", channel)
+}
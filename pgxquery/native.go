@@ -0,0 +1,87 @@
+package pgxquery
+
+import (
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pkg/errors"
+)
+
+// scanAllNative scans the rows into a slice of structs by decoding the raw values of the columns straight into the fields
+// with the scan plans of the type map of the connection, which are planned once for the rows.
+// The fields of the columns come from the struct plan of the dbquery API, see dbquery.API.DirectFieldIndexes.
+// It reports false without reading the rows when the destination or the columns need the generic path of ScanAll,
+// or when the rows don't come from a connection.
+func (api *API) scanAllNative(dst interface{}, rows pgx.Rows) (bool, error) {
+	if api.tables != nil {
+		// The qualified columns are looked up with the context of the query.
+		return false, nil
+	}
+	conn := rows.Conn()
+	if conn == nil {
+		return false, nil
+	}
+
+	dstVal := reflect.ValueOf(dst)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() || dstVal.Elem().Kind() != reflect.Slice {
+		return false, nil
+	}
+	sliceVal := dstVal.Elem()
+	structType := sliceVal.Type().Elem()
+	elementByPtr := structType.Kind() == reflect.Ptr
+	if elementByPtr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return false, nil
+	}
+
+	fields := rows.FieldDescriptions()
+	columns := make([]string, len(fields))
+	for i, fd := range fields {
+		columns[i] = fd.Name
+	}
+	fieldIndexes, ok := api.dbqueryAPI.DirectFieldIndexes(structType, columns)
+	if !ok {
+		return false, nil
+	}
+
+	defer rows.Close()
+	sliceVal.Set(sliceVal.Slice(0, 0))
+	typeMap := conn.TypeMap()
+	plans := make([]pgtype.ScanPlan, len(fields))
+	for rows.Next() {
+		values := rows.RawValues()
+		length := sliceVal.Len()
+		var element reflect.Value
+		if elementByPtr {
+			element = reflect.New(structType)
+		} else {
+			// Decoding straight into the appended element saves allocating it separately.
+			sliceVal.Set(reflect.Append(sliceVal, reflect.Zero(structType)))
+			element = sliceVal.Index(length).Addr()
+		}
+
+		for i, fieldIndex := range fieldIndexes {
+			if fieldIndex == nil {
+				continue
+			}
+			target := element.Elem().FieldByIndex(fieldIndex).Addr().Interface()
+			if plans[i] == nil {
+				plans[i] = typeMap.PlanScan(fields[i].DataTypeOID, fields[i].Format, target)
+			}
+			if err := plans[i].Scan(values[i], target); err != nil {
+				sliceVal.Set(sliceVal.Slice(0, length))
+				return true, errors.Wrapf(err, "orava: scan row into struct fields: column: '%s'", columns[i])
+			}
+		}
+
+		if elementByPtr {
+			sliceVal.Set(reflect.Append(sliceVal, element))
+		}
+	}
+
+	rows.Close()
+	return true, errors.Wrap(rows.Err(), "orava: rows final error")
+}
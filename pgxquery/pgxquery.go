@@ -25,24 +25,32 @@ var (
 
 type API struct {
 	dbqueryAPI *dbquery.API
+	tables     *tableCatalog
+	statements *statementRegistry
 }
 
 // NewAPI creates new API instance from dbquery.API instance.
-func NewAPI(dbqueryAPI *dbquery.API) (*API, error) {
+func NewAPI(dbqueryAPI *dbquery.API, opts ...APIOption) (*API, error) {
 	api := &API{
 		dbqueryAPI: dbqueryAPI,
 	}
+
+	for _, o := range opts {
+		o(api)
+	}
+
 	return api, nil
 }
 
 // Select is a high-level function that queries rows from Querier and calls the ScanAll function.
 // See ScanAll for details.
 func (api *API) Select(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) error {
+	ctx = withDestination(ctx, dst)
 	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
 		return errors.Wrap(err, "orava: query multiple result rows")
 	}
-	err = api.ScanAll(dst, rows)
+	err = api.ScanAll(dst, api.withQueryContext(ctx, rows))
 	return errors.WithStack(err)
 }
 
@@ -53,18 +61,20 @@ func (api *API) SelectNamed(ctx context.Context, db Querier, dst interface{}, qu
 	if err != nil {
 		return err
 	}
+	ctx = withNamedQuery(ctx, query, compiledQuery, dst)
 
-	return api.Select(ctx, db, dst, compiledQuery, args)
+	return api.Select(ctx, db, dst, compiledQuery, args...)
 }
 
 // Get is a high-level function that queries rows from Querier and calls the ScanOne function.
 // See ScanOne for details.
 func (api *API) Get(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) error {
+	ctx = withDestination(ctx, dst)
 	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
 		return errors.Wrap(err, "orava: query one result row")
 	}
-	err = api.ScanOne(dst, rows)
+	err = api.ScanOne(dst, api.withQueryContext(ctx, rows))
 	return errors.WithStack(err)
 }
 
@@ -75,8 +85,57 @@ func (api *API) GetNamed(ctx context.Context, db Querier, dst interface{}, query
 	if err != nil {
 		return err
 	}
+	ctx = withNamedQuery(ctx, query, compiledQuery, dst)
+
+	return api.Get(ctx, db, dst, compiledQuery, args...)
+}
+
+// GetFirst is a high-level function that queries rows from Querier and calls the ScanFirst function.
+// See ScanFirst for details.
+func (api *API) GetFirst(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) error {
+	ctx = withDestination(ctx, dst)
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "orava: query first result row")
+	}
+	err = api.ScanFirst(dst, api.withQueryContext(ctx, rows))
+	return errors.WithStack(err)
+}
+
+// GetFirstNamed is a high-level function that queries rows from Querier and calls the ScanFirst function.
+// See ScanFirst for details.
+func (api *API) GetFirstNamed(ctx context.Context, db Querier, dst interface{}, query string, arg interface{}) error {
+	compiledQuery, args, err := api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return err
+	}
+	ctx = withNamedQuery(ctx, query, compiledQuery, dst)
+
+	return api.GetFirst(ctx, db, dst, compiledQuery, args...)
+}
+
+// GetOptional is a high-level function that queries rows from Querier and calls the ScanOptional function.
+// See ScanOptional for details.
+func (api *API) GetOptional(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) (bool, error) {
+	ctx = withDestination(ctx, dst)
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "orava: query optional result row")
+	}
+	found, err := api.ScanOptional(dst, api.withQueryContext(ctx, rows))
+	return found, errors.WithStack(err)
+}
 
-	return api.Get(ctx, db, dst, compiledQuery, args)
+// GetOptionalNamed is a high-level function that queries rows from Querier and calls the ScanOptional function.
+// See ScanOptional for details.
+func (api *API) GetOptionalNamed(ctx context.Context, db Querier, dst interface{}, query string, arg interface{}) (bool, error) {
+	compiledQuery, args, err := api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return false, err
+	}
+	ctx = withNamedQuery(ctx, query, compiledQuery, dst)
+
+	return api.GetOptional(ctx, db, dst, compiledQuery, args...)
 }
 
 // Exec is a high-level function that sends an executable action to the database
@@ -95,8 +154,9 @@ func (api *API) ExecNamed(ctx context.Context, db Querier, query string, arg int
 	if err != nil {
 		return pgconn.CommandTag{}, err
 	}
+	ctx = withNamedQuery(ctx, query, compiledQuery, nil)
 
-	return api.Exec(ctx, db, compiledQuery, args)
+	return api.Exec(ctx, db, compiledQuery, args...)
 }
 
 // QueryNamed is a high-level function that is used to retrieve pgx.Rows from the database with named parameters
@@ -105,18 +165,21 @@ func (api *API) QueryNamed(ctx context.Context, db Querier, query string, arg in
 	if err != nil {
 		return nil, err
 	}
+	ctx = withNamedQuery(ctx, query, compiledQuery, nil)
 
-	return api.Query(ctx, db, compiledQuery, args)
+	return api.Query(ctx, db, compiledQuery, args...)
 }
 
 // Query is a wrapper around pgx's own query method
 func (api *API) Query(ctx context.Context, db Querier, query string, args ...interface{}) (pgx.Rows, error) {
-	return db.Query(ctx, query, args)
+	return db.Query(ctx, query, args...)
 }
 
 type PreparedQuery struct {
-	api  *API
-	prep *dbquery.PreparedQuery
+	api       *API
+	prep      *dbquery.PreparedQuery
+	statement string
+	examples  []interface{}
 }
 
 func (api *API) PrepareNamed(query string, assertableStruct ...interface{}) (*PreparedQuery, error) {
@@ -125,7 +188,15 @@ func (api *API) PrepareNamed(query string, assertableStruct ...interface{}) (*Pr
 		return nil, errors.WithStack(err)
 	}
 
-	return &PreparedQuery{api, dbPrep}, nil
+	return api.newPreparedQuery(dbPrep, assertableStruct), nil
+}
+
+func (api *API) newPreparedQuery(prep *dbquery.PreparedQuery, examples []interface{}) *PreparedQuery {
+	pq := &PreparedQuery{api: api, prep: prep, examples: examples}
+	if api.statements != nil && len(prep.Idents()) == 0 {
+		pq.statement = api.statements.register(prep.Query())
+	}
+	return pq
 }
 
 // SelectNamed is a high-level function that queries rows from Querier and calls the ScanAll function.
@@ -135,8 +206,15 @@ func (pq *PreparedQuery) SelectNamed(ctx context.Context, db Querier, dst interf
 	if err != nil {
 		return err
 	}
+	ctx = pq.withQueryInfo(ctx, query, dst)
 
-	return pq.api.Select(ctx, db, dst, query, args)
+	db, query, release, err := pq.prepare(ctx, db, query)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return pq.api.Select(ctx, db, dst, query, args...)
 }
 
 // GetNamed is a high-level function that queries rows from Querier and calls the ScanOne function.
@@ -146,8 +224,15 @@ func (pq *PreparedQuery) GetNamed(ctx context.Context, db Querier, dst interface
 	if err != nil {
 		return err
 	}
+	ctx = pq.withQueryInfo(ctx, query, dst)
+
+	db, query, release, err := pq.prepare(ctx, db, query)
+	if err != nil {
+		return err
+	}
+	defer release()
 
-	return pq.api.Get(ctx, db, dst, query, args)
+	return pq.api.Get(ctx, db, dst, query, args...)
 }
 
 // ExecNamed is a high-level function that sends an executable action to the database with named parameters
@@ -156,18 +241,37 @@ func (pq *PreparedQuery) ExecNamed(ctx context.Context, db Querier, arg interfac
 	if err != nil {
 		return pgconn.CommandTag{}, err
 	}
+	ctx = pq.withQueryInfo(ctx, query, nil)
+
+	db, query, release, err := pq.prepare(ctx, db, query)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	defer release()
 
-	return pq.api.Exec(ctx, db, query, args)
+	return pq.api.Exec(ctx, db, query, args...)
 }
 
-// QueryNamed is a high-level function that is used to retrieve *sql.Rows from the database with named parameters
+// QueryNamed is a high-level function that is used to retrieve *sql.Rows from the database with named parameters.
+// When the API was created WithServerPrepare and db is a *pgxpool.Pool, the rows hold the connection their statement was prepared on
+// until they are closed, so they must always be closed.
 func (pq *PreparedQuery) QueryNamed(ctx context.Context, db Querier, arg interface{}) (pgx.Rows, error) {
 	query, args, err := pq.prep.GetQuery(arg)
 	if err != nil {
 		return nil, err
 	}
+	ctx = pq.withQueryInfo(ctx, query, nil)
 
-	return db.Query(ctx, query, args)
+	db, query, release, err := pq.prepare(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &releasingRows{Rows: rows, release: release}, nil
 }
 
 // NotFound is a helper function to check if an error
@@ -178,8 +282,14 @@ func NotFound(err error) bool {
 
 // ScanAll is a wrapper around the dbscan.ScanAll function.
 // See dbscan.ScanAll for details.
+// The rows of a slice of structs, which columns are all scanned straight into the struct fields,
+// are decoded from their raw values with the scan plans of the type map of the connection
+// instead of going through RowsAdapter, the rest go through dbquery.ScanAll.
 func (api *API) ScanAll(dst interface{}, rows pgx.Rows) error {
-	err := api.dbqueryAPI.ScanAll(dst, NewRowsAdapter(rows))
+	if scanned, err := api.scanAllNative(dst, rows); scanned {
+		return errors.WithStack(err)
+	}
+	err := api.dbqueryAPI.ScanAll(dst, api.NewRowsAdapter(rows))
 	return errors.WithStack(err)
 }
 
@@ -187,24 +297,126 @@ func (api *API) ScanAll(dst interface{}, rows pgx.Rows) error {
 // See dbscan.ScanOne for details. If no rows are found it
 // returns a pgx.ErrNoRows error.
 func (api *API) ScanOne(dst interface{}, rows pgx.Rows) error {
-	err := api.dbqueryAPI.ScanOne(dst, NewRowsAdapter(rows))
-	if NotFound(err) {
+	err := api.dbqueryAPI.ScanOne(dst, api.NewRowsAdapter(rows))
+	if dbquery.NotFound(err) {
 		return errors.WithStack(pgx.ErrNoRows)
 	}
 	return errors.WithStack(err)
 }
 
+// ScanFirst is a wrapper around the dbquery.ScanFirst function.
+// See dbquery.ScanFirst for details. If no rows are found it
+// returns a pgx.ErrNoRows error.
+func (api *API) ScanFirst(dst interface{}, rows pgx.Rows) error {
+	err := api.dbqueryAPI.ScanFirst(dst, api.NewRowsAdapter(rows))
+	if dbquery.NotFound(err) {
+		return errors.WithStack(pgx.ErrNoRows)
+	}
+	return errors.WithStack(err)
+}
+
+// ScanOptional is a wrapper around the dbquery.ScanOptional function.
+// See dbquery.ScanOptional for details.
+func (api *API) ScanOptional(dst interface{}, rows pgx.Rows) (bool, error) {
+	found, err := api.dbqueryAPI.ScanOptional(dst, api.NewRowsAdapter(rows))
+	return found, errors.WithStack(err)
+}
+
 // ScanRow is a wrapper around the dbscan.ScanRow function.
 // See dbscan.ScanRow for details.
 func (api *API) ScanRow(dst interface{}, rows pgx.Rows) error {
-	err := api.dbqueryAPI.ScanRow(dst, NewRowsAdapter(rows))
+	err := api.dbqueryAPI.ScanRow(dst, api.NewRowsAdapter(rows))
+	return errors.WithStack(err)
+}
+
+// SelectIndexed is a high-level function that queries rows from Querier and calls the ScanIndexed function.
+// See ScanIndexed for details.
+func (api *API) SelectIndexed(ctx context.Context, db Querier, dst interface{}, keyColumn string, query string, args ...interface{}) error {
+	ctx = withDestination(ctx, dst)
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "orava: query indexed rows")
+	}
+	err = api.ScanIndexed(dst, api.withQueryContext(ctx, rows), keyColumn)
 	return errors.WithStack(err)
 }
 
+// SelectIndexedNamed is a high-level function that queries rows from Querier and calls the ScanIndexed function.
+// See ScanIndexed for details.
+func (api *API) SelectIndexedNamed(ctx context.Context, db Querier, dst interface{}, keyColumn string, query string, arg interface{}) error {
+	compiledQuery, args, err := api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return err
+	}
+	ctx = withNamedQuery(ctx, query, compiledQuery, dst)
+
+	return api.SelectIndexed(ctx, db, dst, keyColumn, compiledQuery, args...)
+}
+
+// ScanIndexed is a wrapper around the dbquery.ScanIndexed function.
+// See dbquery.ScanIndexed for details.
+func (api *API) ScanIndexed(dst interface{}, rows pgx.Rows, keyColumn string) error {
+	err := api.dbqueryAPI.ScanIndexed(dst, api.NewRowsAdapter(rows), keyColumn)
+	return errors.WithStack(err)
+}
+
+// SelectColumn is a high-level function that queries rows from Querier and calls the ScanColumn function.
+// See ScanColumn for details.
+func SelectColumn[T any](ctx context.Context, api *API, db Querier, query string, args ...interface{}) ([]T, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "orava: query column rows")
+	}
+	values, err := ScanColumn[T](api, api.withQueryContext(ctx, rows))
+	return values, errors.WithStack(err)
+}
+
+// SelectColumnNamed is a high-level function that queries rows from Querier and calls the ScanColumn function.
+// See ScanColumn for details.
+func SelectColumnNamed[T any](ctx context.Context, api *API, db Querier, query string, arg interface{}) ([]T, error) {
+	compiledQuery, args, err := api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withNamedQuery(ctx, query, compiledQuery, nil)
+
+	return SelectColumn[T](ctx, api, db, compiledQuery, args...)
+}
+
+// ScanColumn is a wrapper around the dbquery.ScanColumn function.
+// See dbquery.ScanColumn for details.
+func ScanColumn[T any](api *API, rows pgx.Rows) ([]T, error) {
+	values, err := dbquery.ScanColumn[T](api.dbqueryAPI, api.NewRowsAdapter(rows))
+	return values, errors.WithStack(err)
+}
+
+// NewRowScanner returns a new RowScanner instance wrapping the pgx.Rows.
+// See dbquery.RowScanner for details.
+func (api *API) NewRowScanner(rows pgx.Rows) *dbquery.RowScanner {
+	return api.dbqueryAPI.NewRowScanner(api.NewRowsAdapter(rows))
+}
+
 // RowsAdapter makes pgx.Rows compliant with the dbscan.Rows interface.
 // See dbscan.Rows for details.
 type RowsAdapter struct {
 	pgx.Rows
+	api *API
+	ctx context.Context
+}
+
+// queryRows keeps the context of the query with its rows,
+// the table names of the qualified columns are looked up with it.
+type queryRows struct {
+	pgx.Rows
+	ctx context.Context
+}
+
+// withQueryContext attaches the context of the query to the rows if the API qualifies the columns.
+func (api *API) withQueryContext(ctx context.Context, rows pgx.Rows) pgx.Rows {
+	if api.tables == nil {
+		return rows
+	}
+	return queryRows{Rows: rows, ctx: ctx}
 }
 
 // NewRowsAdapter returns a new RowsAdapter instance.
@@ -212,15 +424,46 @@ func NewRowsAdapter(rows pgx.Rows) *RowsAdapter {
 	return &RowsAdapter{Rows: rows}
 }
 
+// NewRowsAdapter returns a new RowsAdapter instance,
+// which qualifies the columns if the API was created WithQualifiedColumns.
+func (api *API) NewRowsAdapter(rows pgx.Rows) *RowsAdapter {
+	ra := &RowsAdapter{Rows: rows, api: api, ctx: context.Background()}
+	if qr, ok := rows.(queryRows); ok {
+		ra.Rows, ra.ctx = qr.Rows, qr.ctx
+	}
+	return ra
+}
+
 // Columns implements the dbscan.Rows.Columns method.
 func (ra RowsAdapter) Columns() ([]string, error) {
 	columns := make([]string, len(ra.Rows.FieldDescriptions()))
 	for i, fd := range ra.Rows.FieldDescriptions() {
 		columns[i] = string(fd.Name)
 	}
+	if ra.api != nil && ra.api.tables != nil {
+		ctx := ra.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return ra.api.tables.qualify(ctx, columns, ra.Rows.FieldDescriptions(), ra.api.dbqueryAPI.ColumnSeparator())
+	}
 	return columns, nil
 }
 
+// UnqualifiedColumns implements the dbquery.QualifiedRows.UnqualifiedColumns method.
+func (ra RowsAdapter) UnqualifiedColumns() ([]string, error) {
+	columns := make([]string, len(ra.Rows.FieldDescriptions()))
+	for i, fd := range ra.Rows.FieldDescriptions() {
+		columns[i] = string(fd.Name)
+	}
+	return columns, nil
+}
+
+// Scan implements the dbscan.Rows.Scan method.
+func (ra RowsAdapter) Scan(dest ...interface{}) error {
+	return ra.Rows.Scan(dest...)
+}
+
 // Close implements the dbscan.Rows.Close method.
 func (ra RowsAdapter) Close() error {
 	ra.Rows.Close()
@@ -2,14 +2,22 @@ package pgxquery_test
 
 import (
 	"context"
+	"database/sql"
+	stderrors "errors"
 	"flag"
 	"os"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/anton7r/orava/dbquery"
 	"github.com/anton7r/orava/pgxquery"
 	"github.com/cockroachdb/cockroach-go/v2/testserver"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
@@ -183,14 +191,15 @@ func TestRowScanner_Scan_NULLableScannerType(t *testing.T) {
 		{
 			name:     "NULL value",
 			query:    `SELECT NULL as foo`,
-			expected: &Destination{Foo: pgtype.Text{Status: pgtype.Null}},
+			expected: &Destination{Foo: pgtype.Text{Valid: false}},
 		},
 		{
 			name:     "non NULL value",
 			query:    `SELECT 'foo value' as foo`,
-			expected: &Destination{Foo: pgtype.Text{String: "foo value", Status: pgtype.Present}},
+			expected: &Destination{Foo: pgtype.Text{String: "foo value", Valid: true}},
 		},
 	} {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
@@ -226,6 +235,707 @@ func TestScanRow(t *testing.T) {
 	assert.Equal(t, expected, got)
 }
 
+func TestSelectColumn(t *testing.T) {
+	t.Parallel()
+
+	got, err := pgxquery.SelectColumn[string](ctx, testAPI, testDB, `SELECT foo FROM (`+multipleRowsQuery+`) AS q`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"foo val", "foo val 2", "foo val 3"}, got)
+}
+
+func TestSelectIndexed(t *testing.T) {
+	t.Parallel()
+	expected := map[string]*testModel{
+		"foo val":   {Foo: "foo val", Bar: "bar val"},
+		"foo val 2": {Foo: "foo val 2", Bar: "bar val 2"},
+		"foo val 3": {Foo: "foo val 3", Bar: "bar val 3"},
+	}
+
+	var got map[string]*testModel
+	err := testAPI.SelectIndexed(ctx, testDB, &got, "foo", multipleRowsQuery)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, got)
+}
+
+func TestSelect_qualifiedColumns(t *testing.T) {
+	t.Parallel()
+	_, err := testDB.Exec(ctx, `
+		CREATE TABLE qualified_users (id INT PRIMARY KEY, name TEXT);
+		CREATE TABLE qualified_orders (id INT PRIMARY KEY, user_id INT, total INT);
+		INSERT INTO qualified_users VALUES (1, 'bob');
+		INSERT INTO qualified_orders VALUES (10, 1, 100);
+	`)
+	require.NoError(t, err)
+
+	api, err := pgxquery.NewAPI(dbquery.DefaultAPI, pgxquery.WithQualifiedColumns(testDB))
+	require.NoError(t, err)
+
+	type order struct {
+		ID int64
+	}
+	type userOrder struct {
+		ID    int64
+		Name  string
+		Total int64
+		Order order `db:"qualified_orders"`
+	}
+
+	// Only the duplicate id columns are qualified.
+	var got []userOrder
+	err = api.Select(ctx, testDB, &got, `
+		SELECT u.id, u.name, o.id, o.total
+		FROM qualified_users u JOIN qualified_orders o ON o.user_id = u.id
+	`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []userOrder{{ID: 1, Name: "bob", Total: 100, Order: order{ID: 10}}}, got)
+
+	// The ids of a table joined to itself are still the same after qualifying them.
+	err = api.Select(ctx, testDB, &got, `
+		SELECT u.id, u.name, m.id
+		FROM qualified_users u JOIN qualified_users m ON m.id = u.id
+	`)
+	assert.Error(t, err)
+
+	// The tables of the same name in different schemas are qualified with their schemas.
+	_, err = testDB.Exec(ctx, `
+		CREATE SCHEMA qualified_billing;
+		CREATE TABLE qualified_billing.qualified_users (id INT PRIMARY KEY);
+		INSERT INTO qualified_billing.qualified_users VALUES (20);
+	`)
+	require.NoError(t, err)
+
+	type billingUser struct {
+		ID int64
+	}
+	type billing struct {
+		User billingUser `db:"qualified_users"`
+	}
+	type userBilling struct {
+		ID      int64
+		Name    string
+		Billing billing `db:"qualified_billing"`
+	}
+	var billed []userBilling
+	err = api.Select(ctx, testDB, &billed, `
+		SELECT u.id, u.name, b.id
+		FROM qualified_users u CROSS JOIN qualified_billing.qualified_users b
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, []userBilling{{ID: 1, Name: "bob", Billing: billing{User: billingUser{ID: 20}}}}, billed)
+}
+
+func TestGetFirst(t *testing.T) {
+	t.Parallel()
+	expected := testModel{Foo: "foo val", Bar: "bar val"}
+
+	var got testModel
+	err := testAPI.GetFirst(ctx, testDB, &got, multipleRowsQuery)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, got)
+
+	err = testAPI.GetFirst(ctx, testDB, &got, noRowsQuery)
+	assert.True(t, pgxquery.NotFound(err))
+}
+
+func TestLoadQueries(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"models.sql": &fstest.MapFile{Data: []byte("-- name: GetModel :one\nSELECT foo, bar FROM (" + multipleRowsQuery + ") AS q WHERE foo = :foo\n")},
+	}
+	registry, err := testAPI.LoadQueries(fsys, "*.sql", map[string][]interface{}{"GetModel": {testModel{}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GetModel"}, registry.Names())
+
+	var got testModel
+	err = registry.MustGet("GetModel").GetNamed(ctx, testDB, &got, &testModel{Foo: "foo val 2"})
+	require.NoError(t, err)
+	assert.Equal(t, testModel{Foo: "foo val 2", Bar: "bar val 2"}, got)
+}
+
+func TestGetOptional(t *testing.T) {
+	t.Parallel()
+
+	var got testModel
+	found, err := testAPI.GetOptional(ctx, testDB, &got, noRowsQuery)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	found, err = testAPI.GetOptional(ctx, testDB, &got, singleRowsQuery)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, testModel{Foo: "foo val", Bar: "bar val"}, got)
+}
+
+func TestPreparedQuery_serverPrepare(t *testing.T) {
+	t.Parallel()
+	dbqueryAPI, err := dbquery.NewAPI(dbquery.WithLexer(':', dbquery.SequentialDollarDelim))
+	require.NoError(t, err)
+	api, err := pgxquery.NewAPI(dbqueryAPI, pgxquery.WithServerPrepare())
+	require.NoError(t, err)
+
+	pq, err := api.PrepareNamed(`SELECT foo, bar FROM (`+multipleRowsQuery+`) AS q WHERE foo = :foo`, testModel{})
+	require.NoError(t, err)
+	require.NoError(t, pq.Verify(ctx, testDB))
+
+	for i := 0; i < 2; i++ {
+		var got []*testModel
+		err = pq.SelectNamed(ctx, testDB, &got, &testModel{Foo: "foo val 2"})
+		require.NoError(t, err)
+		assert.Equal(t, []*testModel{{Foo: "foo val 2", Bar: "bar val 2"}}, got)
+	}
+
+	bad, err := api.PrepareNamed(`SELECT foo FROM missing_table WHERE foo = :foo`, testModel{})
+	require.NoError(t, err)
+	assert.Error(t, bad.Verify(ctx, testDB))
+
+	// Each call holds a connection of the pool while it runs, so more callers than connections take turns.
+	config := testDB.Config()
+	config.MaxConns = 2
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var got []*testModel
+			if err := pq.SelectNamed(ctx, pool, &got, &testModel{Foo: "foo val 2"}); err != nil {
+				errs <- err
+				return
+			}
+			var one testModel
+			if err := pq.GetNamed(ctx, pool, &one, &testModel{Foo: "foo val 2"}); err != nil {
+				errs <- err
+				return
+			}
+			if len(got) != 1 || one != *got[0] {
+				errs <- errors.Errorf("unexpected rows: %v, %v", got, one)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(0), pool.Stat().AcquiredConns())
+}
+
+func TestPreparedQuery_Verify(t *testing.T) {
+	t.Parallel()
+	type arg struct {
+		ID  int64
+		Foo sql.NullString
+		Bar pgtype.Text
+	}
+
+	pq, err := testAPI.PrepareNamed(`SELECT foo, bar FROM (`+multipleRowsQuery+`) AS q WHERE length(foo) > :id AND foo <> :foo AND bar <> :bar`, arg{})
+	require.NoError(t, err)
+	require.NoError(t, pq.Verify(ctx, testDB, &[]testModel{}))
+
+	type wrongArg struct {
+		ID []string
+	}
+	type wrongDst struct {
+		Foo string
+		Baz string
+	}
+	pq, err = testAPI.PrepareNamed(`SELECT foo, bar FROM (`+multipleRowsQuery+`) AS q WHERE length(foo) > :id`, wrongArg{})
+	require.NoError(t, err)
+	err = pq.Verify(ctx, testDB, &[]wrongDst{})
+
+	var verifyErr *pgxquery.VerifyError
+	require.ErrorAs(t, err, &verifyErr)
+	assert.Len(t, verifyErr.Mismatches, 2)
+
+	// A param naming a nested struct has no field to bind it from.
+	type nestedArg struct {
+		Model testModel
+	}
+	pq, err = testAPI.PrepareNamed(`SELECT foo, bar FROM (`+multipleRowsQuery+`) AS q WHERE foo = :model`, nestedArg{})
+	require.NoError(t, err)
+	err = pq.Verify(ctx, testDB)
+	require.ErrorAs(t, err, &verifyErr)
+	assert.Len(t, verifyErr.Mismatches, 1)
+}
+
+func TestSlowQueryTracer(t *testing.T) {
+	t.Parallel()
+	var traced []pgxquery.SlowQuery
+	tracer := &pgxquery.SlowQueryTracer{
+		Log: func(ctx context.Context, query pgxquery.SlowQuery) {
+			traced = append(traced, query)
+		},
+	}
+	config := testDB.Config()
+	config.ConnConfig.Tracer = tracer
+	db, err := pgxpool.NewWithConfig(ctx, config)
+	require.NoError(t, err)
+	defer db.Close()
+
+	query := `SELECT foo, bar FROM (` + multipleRowsQuery + `) AS q WHERE foo = :foo`
+	pq, err := testAPI.PrepareNamed(query, testModel{})
+	require.NoError(t, err)
+
+	var got []testModel
+	err = pq.SelectNamed(ctx, db, &got, &testModel{Foo: "foo val"})
+	require.NoError(t, err)
+
+	require.Len(t, traced, 1)
+	assert.Equal(t, query, traced[0].NamedQuery)
+	assert.Contains(t, traced[0].SQL, "foo = $1")
+	assert.Equal(t, traced[0].SQL, traced[0].Query)
+	assert.Equal(t, reflect.TypeOf(&got), traced[0].Destination)
+
+	// The statement name is sent as the SQL of the prepared queries, their compiled query is in the QueryInfo.
+	dbqueryAPI, err := dbquery.NewAPI(dbquery.WithLexer(':', dbquery.SequentialDollarDelim))
+	require.NoError(t, err)
+	api, err := pgxquery.NewAPI(dbqueryAPI, pgxquery.WithServerPrepare())
+	require.NoError(t, err)
+	pq, err = api.PrepareNamed(query, testModel{})
+	require.NoError(t, err)
+	traced = nil
+	err = pq.SelectNamed(ctx, db, &got, &testModel{Foo: "foo val"})
+	require.NoError(t, err)
+
+	require.NotEmpty(t, traced)
+	last := traced[len(traced)-1]
+	assert.Equal(t, last.Statement, last.SQL)
+	assert.Contains(t, last.Query, "foo = $1")
+
+	// The queries faster than the threshold are left out.
+	tracer.Threshold = time.Hour
+	traced = nil
+	err = pq.SelectNamed(ctx, db, &got, &testModel{Foo: "foo val"})
+	require.NoError(t, err)
+	assert.Empty(t, traced)
+}
+
+func TestCursor(t *testing.T) {
+	t.Parallel()
+	tx, err := testDB.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx) // nolint: errcheck
+
+	var chunk []*testModel
+	cursor, err := testAPI.Cursor(ctx, tx, &chunk, multipleRowsQuery, nil, 2)
+	require.NoError(t, err)
+	defer cursor.Close(ctx) // nolint: errcheck
+
+	var chunks [][]*testModel
+	for cursor.Next(ctx) {
+		chunks = append(chunks, append([]*testModel(nil), chunk...))
+	}
+	require.NoError(t, cursor.Err())
+
+	expected := [][]*testModel{
+		{{Foo: "foo val", Bar: "bar val"}, {Foo: "foo val 2", Bar: "bar val 2"}},
+		{{Foo: "foo val 3", Bar: "bar val 3"}},
+	}
+	assert.Equal(t, expected, chunks)
+
+	// A chunk as large as the fetch size isn't taken for the last one.
+	cursor, err = testAPI.Cursor(ctx, tx, &chunk, multipleRowsQuery, nil, 3)
+	require.NoError(t, err)
+	chunks = nil
+	for cursor.Next(ctx) {
+		chunks = append(chunks, append([]*testModel(nil), chunk...))
+	}
+	require.NoError(t, cursor.Err())
+	assert.Len(t, chunks, 1)
+
+	type item struct {
+		Bar string
+	}
+	type aggregated struct {
+		Foo   string `db:"foo,pk"`
+		Items []item `db:"items"`
+	}
+	var aggregatedChunk []aggregated
+	_, err = testAPI.Cursor(ctx, tx, &aggregatedChunk, multipleRowsQuery, nil, 2)
+	assert.Error(t, err)
+}
+
+func TestRowTo(t *testing.T) {
+	t.Parallel()
+	expected := []testModel{
+		{Foo: "foo val", Bar: "bar val"},
+		{Foo: "foo val 2", Bar: "bar val 2"},
+		{Foo: "foo val 3", Bar: "bar val 3"},
+	}
+
+	rows, err := testDB.Query(ctx, multipleRowsQuery)
+	require.NoError(t, err)
+	got, err := pgx.CollectRows(rows, pgxquery.RowTo[testModel](testAPI))
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, got)
+
+	rows, err = testDB.Query(ctx, singleRowsQuery)
+	require.NoError(t, err)
+	one, err := pgx.CollectOneRow(rows, pgxquery.RowToAddrOf[testModel](testAPI))
+	require.NoError(t, err)
+
+	assert.Equal(t, &expected[0], one)
+}
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+	batch := testAPI.NewBatch()
+	batch.Queue(multipleRowsQuery)
+	require.NoError(t, batch.QueueNamed(`SELECT :foo AS foo, 'bar val' AS bar`, map[string]interface{}{"foo": "named foo"}))
+	batch.Queue(`SELECT 1`)
+	assert.Equal(t, 3, batch.Len())
+
+	results := batch.Send(ctx, testDB)
+	defer results.Close()
+
+	var many []*testModel
+	require.NoError(t, results.Select(&many))
+	assert.Len(t, many, 3)
+
+	var one testModel
+	require.NoError(t, results.Get(&one))
+	assert.Equal(t, testModel{Foo: "named foo", Bar: "bar val"}, one)
+
+	_, err := results.Exec()
+	require.NoError(t, err)
+	require.NoError(t, results.Close())
+}
+
+func TestCopyStructs(t *testing.T) {
+	t.Parallel()
+	_, err := testDB.Exec(ctx, `CREATE TABLE copied_models (foo TEXT, bar TEXT)`)
+	require.NoError(t, err)
+
+	models := []*testModel{
+		{Foo: "foo val", Bar: "bar val"},
+		{Foo: "foo val 2", Bar: "bar val 2"},
+	}
+	count, err := testAPI.CopyStructs(ctx, testDB, pgx.Identifier{"copied_models"}, models)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	ch := make(chan testModel, 1)
+	go func() {
+		ch <- testModel{Foo: "foo val 3", Bar: "bar val 3"}
+		close(ch)
+	}()
+	count, err = testAPI.CopyStructs(ctx, testDB, pgx.Identifier{"copied_models"}, ch)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	var got []*testModel
+	err = testAPI.Select(ctx, testDB, &got, `SELECT foo, bar FROM copied_models ORDER BY foo`)
+	require.NoError(t, err)
+	assert.Equal(t, append(models, &testModel{Foo: "foo val 3", Bar: "bar val 3"}), got)
+
+	// The channel is left open, so only the context ends the copy.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = testAPI.CopyStructs(canceledCtx, testDB, pgx.Identifier{"copied_models"}, make(chan testModel))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// The fields of embedded structs are columns of the table, the fields of named nested structs aren't.
+	type embedded struct {
+		*testModel
+	}
+	count, err = testAPI.CopyStructs(ctx, testDB, pgx.Identifier{"copied_models"}, []embedded{{&testModel{Foo: "foo val 4"}}, {}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	type nested struct {
+		Model testModel
+	}
+	_, err = testAPI.CopyStructs(ctx, testDB, pgx.Identifier{"copied_models"}, []nested{{}})
+	assert.Error(t, err)
+}
+
+func TestCopyStructs_valueTypes(t *testing.T) {
+	t.Parallel()
+	_, err := testDB.Exec(ctx, `CREATE TABLE copied_notes (id INT, note TEXT, created TIMESTAMPTZ)`)
+	require.NoError(t, err)
+
+	type note struct {
+		ID      int64
+		Note    sql.NullString
+		Created pgtype.Timestamptz
+	}
+
+	created := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	notes := []note{
+		{ID: 1, Note: sql.NullString{String: "note", Valid: true}, Created: pgtype.Timestamptz{Time: created, Valid: true}},
+		{ID: 2},
+	}
+	count, err := testAPI.CopyStructs(ctx, testDB, pgx.Identifier{"public", "copied_notes"}, notes)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	var got []note
+	err = testAPI.Select(ctx, testDB, &got, `SELECT id, note, created FROM copied_notes ORDER BY id`)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, notes[0].Note, got[0].Note)
+	assert.True(t, got[0].Created.Valid && created.Equal(got[0].Created.Time))
+	assert.Equal(t, notes[1], got[1])
+}
+
+func TestCopyOut(t *testing.T) {
+	t.Parallel()
+	query := `SELECT * FROM (VALUES ('foo val', 'bar' || chr(9) || 'val'), ('foo val 2', NULL)) AS t (foo, bar)`
+
+	type model struct {
+		Foo string
+		Bar *string
+	}
+
+	var got []model
+	err := testAPI.CopyOut(ctx, testDB, &got, query)
+	require.NoError(t, err)
+
+	bar := "bar\tval"
+	assert.Equal(t, []model{{Foo: "foo val", Bar: &bar}, {Foo: "foo val 2"}}, got)
+
+	var each []string
+	err = pgxquery.CopyOutEach(ctx, testAPI, testDB, query, func(m model) error {
+		each = append(each, m.Foo)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo val", "foo val 2"}, each)
+
+	csvOut := &strings.Builder{}
+	count, err := testAPI.CopyOutCSV(ctx, testDB, csvOut, query)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, "foo,bar\nfoo val,bar\tval\nfoo val 2,\n", csvOut.String())
+
+	jsonOut := &strings.Builder{}
+	count, err = testAPI.CopyOutJSON(ctx, testDB, jsonOut, query)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, "{\"foo\":\"foo val\",\"bar\":\"bar\\tval\"}\n{\"foo\":\"foo val 2\",\"bar\":null}\n", jsonOut.String())
+}
+
+func TestCopyOut_binary(t *testing.T) {
+	t.Parallel()
+	query := `SELECT * FROM (VALUES (1::INT8, 'foo val', 1.5::FLOAT8, true, '2024-01-02 03:04:05+00'::TIMESTAMPTZ),
+		(2::INT8, NULL, NULL, false, NULL)) AS t (id, foo, ratio, active, created_at)`
+
+	type model struct {
+		ID        int64
+		Foo       *string
+		Ratio     *float64
+		Active    bool
+		CreatedAt *time.Time
+	}
+
+	var got []model
+	err := testAPI.CopyOut(ctx, testDB, &got, query, pgxquery.WithCopyOutBinary())
+	require.NoError(t, err)
+
+	foo := "foo val"
+	ratio := 1.5
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.Len(t, got, 2)
+	require.NotNil(t, got[0].CreatedAt)
+	*got[0].CreatedAt = got[0].CreatedAt.UTC()
+	assert.Equal(t, []model{{ID: 1, Foo: &foo, Ratio: &ratio, Active: true, CreatedAt: &createdAt}, {ID: 2}}, got)
+
+	jsonOut := &strings.Builder{}
+	count, err := testAPI.CopyOutJSON(ctx, testDB, jsonOut, `SELECT 1::INT8 AS id, NULL::TEXT AS foo`, pgxquery.WithCopyOutBinary())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, "{\"id\":1,\"foo\":null}\n", jsonOut.String())
+}
+
+func TestCopyOut_errorKeepsConnection(t *testing.T) {
+	t.Parallel()
+	tx, err := testDB.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	stop := stderrors.New("stop")
+	err = pgxquery.CopyOutEach(ctx, testAPI, tx, multipleRowsQuery, func(m testModel) error {
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+
+	_, err = testAPI.CopyOutCSV(ctx, tx, failingWriter{err: stop}, multipleRowsQuery)
+	assert.ErrorIs(t, err, stop)
+
+	var got []testModel
+	err = testAPI.Select(ctx, tx, &got, multipleRowsQuery)
+	require.NoError(t, err)
+	assert.Len(t, got, 3)
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+const wideRowsQuery = `
+	SELECT i AS id, 'name ' || i::TEXT AS name, i * 1.5 AS ratio, i % 2 = 0 AS active,
+		CASE WHEN i % 3 = 0 THEN NULL ELSE 'note ' || i::TEXT END AS note,
+		'2024-01-02 03:04:05+00'::TIMESTAMPTZ AS created_at, i * 100 AS "address.zip"
+	FROM generate_series(1, $1::INT8) AS i`
+
+type wideAddress struct {
+	Zip int64
+}
+
+type wideRow struct {
+	ID        int64
+	Name      string
+	Ratio     float64
+	Active    bool
+	Note      *string
+	CreatedAt time.Time
+	Address   wideAddress
+}
+
+func TestScanAll_native(t *testing.T) {
+	t.Parallel()
+	rows, err := testDB.Query(ctx, wideRowsQuery, 10)
+	require.NoError(t, err)
+	var got []*wideRow
+	err = testAPI.ScanAll(&got, rows)
+	require.NoError(t, err)
+
+	rows, err = testDB.Query(ctx, wideRowsQuery, 10)
+	require.NoError(t, err)
+	var expected []*wideRow
+	err = dbquery.DefaultAPI.ScanAll(&expected, pgxquery.NewRowsAdapter(rows))
+	require.NoError(t, err)
+
+	require.Len(t, got, 10)
+	assert.Equal(t, expected, got)
+	assert.Nil(t, got[2].Note)
+	assert.Equal(t, int64(1000), got[9].Address.Zip)
+}
+
+func TestScanAll_nativeNullIntoValue(t *testing.T) {
+	t.Parallel()
+	type model struct {
+		Foo string
+	}
+
+	rows, err := testDB.Query(ctx, `SELECT NULL::TEXT AS foo`)
+	require.NoError(t, err)
+	var got []model
+	err = testAPI.ScanAll(&got, rows)
+	assert.ErrorContains(t, err, "orava: scan row into struct fields: column: 'foo'")
+	assert.Empty(t, got)
+}
+
+func benchmarkScanAll(b *testing.B, scanAll func(dst interface{}, rows pgx.Rows) error) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rows, err := testDB.Query(ctx, wideRowsQuery, 1000)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var got []wideRow
+		if err := scanAll(&got, rows); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScanAll_native(b *testing.B) {
+	benchmarkScanAll(b, testAPI.ScanAll)
+}
+
+func BenchmarkScanAll_generic(b *testing.B) {
+	benchmarkScanAll(b, func(dst interface{}, rows pgx.Rows) error {
+		return dbquery.DefaultAPI.ScanAll(dst, pgxquery.NewRowsAdapter(rows))
+	})
+}
+
+func TestListener_dispatch(t *testing.T) {
+	t.Parallel()
+	var errs []error
+	l := testAPI.NewListener(testDB, pgxquery.WithListenErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+
+	var decoded []testModel
+	pgxquery.Handle(l, "models", func(ctx context.Context, payload testModel) error {
+		decoded = append(decoded, payload)
+		return nil
+	})
+	var raw []string
+	l.HandleRaw("raw", func(ctx context.Context, n *pgconn.Notification) error {
+		raw = append(raw, n.Payload)
+		return nil
+	})
+	models := pgxquery.Subscribe[testModel](l, "subscribed", 1)
+
+	l.Dispatch(ctx, &pgconn.Notification{Channel: "models", Payload: `{"Foo":"foo val","Bar":"bar val"}`})
+	l.Dispatch(ctx, &pgconn.Notification{Channel: "raw", Payload: "foo val"})
+	l.Dispatch(ctx, &pgconn.Notification{Channel: "subscribed", Payload: `{"Foo":"foo val 2"}`})
+	l.Dispatch(ctx, &pgconn.Notification{Channel: "unknown", Payload: "{}"})
+	require.Empty(t, errs)
+
+	assert.Equal(t, []testModel{{Foo: "foo val", Bar: "bar val"}}, decoded)
+	assert.Equal(t, []string{"foo val"}, raw)
+	assert.Equal(t, testModel{Foo: "foo val 2"}, <-models)
+
+	// The payloads that aren't JSON are reported to the error handler.
+	l.Dispatch(ctx, &pgconn.Notification{Channel: "models", Payload: "foo val"})
+	assert.Len(t, errs, 1)
+	assert.Len(t, decoded, 1)
+}
+
+func TestNotify(t *testing.T) {
+	t.Parallel()
+	// The probe runs on its own connection, so the pooled connections aren't left listening.
+	probe, err := pgx.ConnectConfig(ctx, testDB.Config().ConnConfig)
+	require.NoError(t, err)
+	_, err = probe.Exec(ctx, `LISTEN orava_probe`)
+	probe.Close(ctx) // nolint: errcheck
+	if err != nil {
+		t.Skipf("the test database doesn't support LISTEN: %v", err)
+	}
+
+	listenCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	l := testAPI.NewListener(testDB, pgxquery.WithListenBackoff(10*time.Millisecond, 100*time.Millisecond))
+	models := pgxquery.Subscribe[testModel](l, "notified_models", 1)
+	raw := make(chan string, 1)
+	l.HandleRaw("notified_raw", func(ctx context.Context, n *pgconn.Notification) error {
+		raw <- n.Payload
+		return nil
+	})
+	go l.Listen(listenCtx) // nolint: errcheck
+
+	// The notifications sent before the Listener is listening are lost, so they are sent until one arrives.
+	expected := testModel{Foo: "foo val", Bar: "bar val"}
+	require.Eventually(t, func() bool {
+		require.NoError(t, testAPI.Notify(ctx, testDB, "notified_models", expected))
+		select {
+		case got := <-models:
+			return assert.Equal(t, expected, got)
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, testAPI.NotifyRaw(ctx, testDB, "notified_raw", "foo val"))
+	assert.Equal(t, "foo val", <-raw)
+}
+
 func TestMain(m *testing.M) {
 	exitCode := func() int {
 		flag.Parse()
@@ -234,7 +944,7 @@ func TestMain(m *testing.M) {
 			panic(err)
 		}
 		defer ts.Stop()
-		testDB, err = pgxpool.Connect(ctx, ts.PGURL().String())
+		testDB, err = pgxpool.New(ctx, ts.PGURL().String())
 		if err != nil {
 			panic(err)
 		}
@@ -0,0 +1,137 @@
+package pgxquery
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+// APIOption configures the API created with NewAPI.
+type APIOption func(api *API)
+
+// WithQualifiedColumns makes the columns that have the same name in the result, like the ids of SELECT u.*, o.*,
+// distinct by qualifying them with the names of their tables, e.g. users.id and orders.id.
+// The qualified columns map to nested structs tagged with the table names,
+// or to the plain fields when only one of them has a field.
+// Tables of the same name in different schemas are qualified with their schemas too, e.g. billing.users.id.
+// The columns of a table joined to itself can't be told apart by their tables and must be aliased.
+// The table names are looked up from pg_class with db and the context of the query, which must not be the connection of the rows,
+// a *pgxpool.Pool for example, and they are cached by their OIDs.
+func WithQualifiedColumns(db Querier) APIOption {
+	return func(api *API) {
+		api.tables = &tableCatalog{db: db, names: map[uint32]tableName{}}
+	}
+}
+
+// tableCatalog looks up and caches the names of the tables by their OIDs.
+type tableCatalog struct {
+	db    Querier
+	mu    sync.Mutex
+	names map[uint32]tableName
+}
+
+// tableName is the name of a table with the name of its schema.
+type tableName struct {
+	schema string
+	name   string
+}
+
+// lookup returns the names of the tables, the missing ones are queried without holding the lock.
+func (c *tableCatalog) lookup(ctx context.Context, oids []uint32) (map[uint32]tableName, error) {
+	c.mu.Lock()
+	names := make(map[uint32]tableName, len(oids))
+	var missing []uint32
+	for _, oid := range oids {
+		if name, found := c.names[oid]; found {
+			names[oid] = name
+		} else {
+			missing = append(missing, oid)
+		}
+	}
+	c.mu.Unlock()
+	if len(missing) == 0 {
+		return names, nil
+	}
+
+	rows, err := c.db.Query(ctx, `
+		SELECT c.oid, n.nspname, c.relname
+		FROM pg_catalog.pg_class c JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.oid = ANY($1)`, missing)
+	if err != nil {
+		return nil, errors.Wrap(err, "orava: look up table names")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var oid uint32
+		var name tableName
+		if err := rows.Scan(&oid, &name.schema, &name.name); err != nil {
+			return nil, errors.Wrap(err, "orava: scan table name")
+		}
+		names[oid] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "orava: look up table names")
+	}
+
+	c.mu.Lock()
+	for _, oid := range missing {
+		c.names[oid] = names[oid]
+	}
+	c.mu.Unlock()
+	return names, nil
+}
+
+// qualify prefixes the names of the columns that appear more than once with the names of their tables.
+// Columns that don't come from a table, like expressions, are left as they are.
+// The columns of a table joined to itself still have the same names, which is reported as an error.
+func (c *tableCatalog) qualify(ctx context.Context, columns []string, fields []pgconn.FieldDescription, separator string) ([]string, error) {
+	counts := make(map[string]int, len(columns))
+	for _, column := range columns {
+		counts[column]++
+	}
+
+	var oids []uint32
+	for i, column := range columns {
+		if counts[column] > 1 && fields[i].TableOID != 0 {
+			oids = append(oids, fields[i].TableOID)
+		}
+	}
+	if len(oids) == 0 {
+		return columns, nil
+	}
+
+	tables, err := c.lookup(ctx, oids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tables of the same name in different schemas are qualified with their schemas too.
+	oidsByName := make(map[string]map[uint32]struct{}, len(tables))
+	for oid, table := range tables {
+		if oidsByName[table.name] == nil {
+			oidsByName[table.name] = map[uint32]struct{}{}
+		}
+		oidsByName[table.name][oid] = struct{}{}
+	}
+
+	qualified := make([]string, len(columns))
+	seen := make(map[string]struct{}, len(columns))
+	for i, column := range columns {
+		qualified[i] = column
+		table, found := tables[fields[i].TableOID]
+		if counts[column] <= 1 || fields[i].TableOID == 0 || !found || table.name == "" {
+			continue
+		}
+		qualified[i] = table.name + separator + column
+		if len(oidsByName[table.name]) > 1 {
+			qualified[i] = table.schema + separator + qualified[i]
+		}
+		if _, found := seen[qualified[i]]; found {
+			return nil, errors.Errorf("orava: column '%s' appears more than once, alias the columns of the tables joined to themselves", qualified[i])
+		}
+		seen[qualified[i]] = struct{}{}
+	}
+	return qualified, nil
+}
@@ -0,0 +1,61 @@
+package pgxquery
+
+import (
+	"github.com/anton7r/orava/dbquery"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// RowTo returns a pgx.RowToFunc that scans the row into T with the mapping of the API,
+// so that it can be used with pgx.CollectRows, pgx.CollectOneRow and pgx.ForEachRow, for example:
+//
+//	users, err := pgx.CollectRows(rows, pgxquery.RowTo[User](api))
+func RowTo[T any](api *API) pgx.RowToFunc[T] {
+	return func(row pgx.CollectableRow) (T, error) {
+		var value T
+		err := api.dbqueryAPI.ScanRow(&value, api.collectableRows(row))
+		return value, errors.WithStack(err)
+	}
+}
+
+// RowToAddrOf is like RowTo, but returns a pointer to the scanned T.
+func RowToAddrOf[T any](api *API) pgx.RowToFunc[*T] {
+	return func(row pgx.CollectableRow) (*T, error) {
+		value := new(T)
+		err := api.dbqueryAPI.ScanRow(value, api.collectableRows(row))
+		return value, errors.WithStack(err)
+	}
+}
+
+func (api *API) collectableRows(row pgx.CollectableRow) dbquery.Rows {
+	if rows, ok := row.(pgx.Rows); ok {
+		return api.NewRowsAdapter(rows)
+	}
+	return &collectableRowAdapter{CollectableRow: row}
+}
+
+// collectableRowAdapter makes the current row of pgx.CollectableRow compliant with the dbquery.Rows interface,
+// the rows are iterated by pgx.
+type collectableRowAdapter struct {
+	pgx.CollectableRow
+}
+
+func (ca *collectableRowAdapter) Columns() ([]string, error) {
+	columns := make([]string, len(ca.FieldDescriptions()))
+	for i, fd := range ca.FieldDescriptions() {
+		columns[i] = fd.Name
+	}
+	return columns, nil
+}
+
+func (ca *collectableRowAdapter) Close() error {
+	return nil
+}
+
+func (ca *collectableRowAdapter) Err() error {
+	return nil
+}
+
+func (ca *collectableRowAdapter) Next() bool {
+	return false
+}
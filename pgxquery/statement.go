@@ -0,0 +1,121 @@
+package pgxquery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// WithServerPrepare makes the queries prepared with PrepareNamed and LoadQueries run as named server-side statements.
+// The statements are prepared on each connection the first time they are used on it,
+// or all at once when the API's AfterConnect is set as the AfterConnect of the pgxpool.Config.
+// After that the queries skip the parsing and reuse the field descriptions of their statements.
+// Queries with {{identifiers}} change with their arguments, so they are still sent as text.
+//
+// The default QueryExecModeCacheStatement of pgx already prepares each query the first time it runs on a connection
+// and caches the statement by its SQL, so with it WithServerPrepare only adds preparing the statements up front
+// with AfterConnect. It pays off with the exec modes that don't cache the statements, like QueryExecModeExec,
+// whose queries are otherwise parsed on every run. The named statements are used in every exec mode.
+// With a *pgxpool.Pool every query of a prepared statement acquires its own connection to prepare the statement on.
+func WithServerPrepare() APIOption {
+	return func(api *API) {
+		api.statements = &statementRegistry{sql: map[string]string{}}
+	}
+}
+
+// statementRegistry holds the SQL of the server-side statements by their names.
+type statementRegistry struct {
+	mu  sync.Mutex
+	sql map[string]string
+}
+
+func (r *statementRegistry) register(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	name := "orava_" + hex.EncodeToString(sum[:8])
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sql[name] = query
+	return name
+}
+
+func (r *statementRegistry) all() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statements := make(map[string]string, len(r.sql))
+	for name, query := range r.sql {
+		statements[name] = query
+	}
+	return statements
+}
+
+// AfterConnect prepares the statements of the queries prepared so far on the connection,
+// it is meant to be set as the AfterConnect of the pgxpool.Config. It does nothing without WithServerPrepare.
+func (api *API) AfterConnect(ctx context.Context, conn *pgx.Conn) error {
+	if api.statements == nil {
+		return nil
+	}
+	for name, query := range api.statements.all() {
+		if _, err := conn.Prepare(ctx, name, query); err != nil {
+			return errors.Wrapf(err, "orava: prepare statement '%s'", name)
+		}
+	}
+	return nil
+}
+
+func (pq *PreparedQuery) describe() string {
+	if name := pq.prep.Name(); name != "" {
+		return "'" + name + "'"
+	}
+	return "'" + pq.prep.Query() + "'"
+}
+
+// prepare returns the querier and the SQL to run the query with, preparing the statement on the connection of db
+// when the query has one. The release function must be called when the querier is no longer used.
+func (pq *PreparedQuery) prepare(ctx context.Context, db Querier, query string) (Querier, string, func(), error) {
+	noop := func() {}
+	if pq.statement == "" {
+		return db, query, noop, nil
+	}
+
+	conn, release, err := acquireConn(ctx, db)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if conn == nil {
+		return db, query, noop, nil
+	}
+
+	if _, err := conn.Prepare(ctx, pq.statement, query); err != nil {
+		release()
+		return nil, "", nil, errors.Wrapf(err, "orava: prepare statement '%s'", pq.statement)
+	}
+	if tx, ok := db.(pgx.Tx); ok {
+		return tx, pq.statement, release, nil
+	}
+	return conn, pq.statement, release, nil
+}
+
+// releasingRows releases the connection of the rows when they are closed.
+type releasingRows struct {
+	pgx.Rows
+	release func()
+	once    sync.Once
+}
+
+func (r *releasingRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.Close()
+	return false
+}
+
+func (r *releasingRows) Close() {
+	r.Rows.Close()
+	r.once.Do(r.release)
+}
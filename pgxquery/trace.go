@@ -0,0 +1,153 @@
+package pgxquery
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// QueryInfo describes the orava call behind a query, a pgx.QueryTracer finds it from the context of the query
+// with QueryInfoFromContext, to report the query by its name rather than by its compiled SQL.
+type QueryInfo struct {
+	// Name is the name of the query when it has been loaded with LoadQueries.
+	Name string
+	// NamedQuery is the query as it was given, with its named params.
+	NamedQuery string
+	// Query is the compiled query of NamedQuery, the SQL of the query is the name of its statement
+	// when the query is run with WithServerPrepare.
+	Query string
+	// Statement is the name of the server-side statement when the query is run with WithServerPrepare.
+	Statement string
+	// Destination is the type of the destination the rows are scanned into.
+	Destination reflect.Type
+}
+
+type queryInfoKey struct{}
+
+// queryInfoContext carries the QueryInfo of a query, so it's attached with a single allocation.
+type queryInfoContext struct {
+	context.Context
+	info QueryInfo
+}
+
+func (c *queryInfoContext) Value(key interface{}) interface{} {
+	if _, ok := key.(queryInfoKey); ok {
+		return c.info
+	}
+	return c.Context.Value(key)
+}
+
+// QueryInfoFromContext returns the QueryInfo that orava has attached to the context of a query.
+func QueryInfoFromContext(ctx context.Context) (QueryInfo, bool) {
+	info, ok := ctx.Value(queryInfoKey{}).(QueryInfo)
+	return info, ok
+}
+
+// withQueryInfo returns a context with a copy of its QueryInfo updated by fn.
+// The QueryInfo attached by the calling orava function is replaced rather than wrapped.
+func withQueryInfo(ctx context.Context, fn func(info *QueryInfo)) context.Context {
+	c := &queryInfoContext{Context: ctx}
+	if parent, ok := ctx.(*queryInfoContext); ok {
+		c.Context, c.info = parent.Context, parent.info
+	} else {
+		c.info, _ = QueryInfoFromContext(ctx)
+	}
+	fn(&c.info)
+	return c
+}
+
+func withNamedQuery(ctx context.Context, namedQuery string, query string, dst interface{}) context.Context {
+	return withQueryInfo(ctx, func(info *QueryInfo) {
+		info.NamedQuery = namedQuery
+		info.Query = query
+		info.Destination = reflect.TypeOf(dst)
+	})
+}
+
+func withDestination(ctx context.Context, dst interface{}) context.Context {
+	dstType := reflect.TypeOf(dst)
+	if c, ok := ctx.(*queryInfoContext); ok && c.info.Destination == dstType {
+		// The named variants have attached it already.
+		return ctx
+	}
+	return withQueryInfo(ctx, func(info *QueryInfo) {
+		info.Destination = dstType
+	})
+}
+
+func (pq *PreparedQuery) withQueryInfo(ctx context.Context, query string, dst interface{}) context.Context {
+	return withQueryInfo(ctx, func(info *QueryInfo) {
+		info.Name = pq.prep.Name()
+		info.NamedQuery = pq.prep.NamedQuery()
+		info.Query = query
+		info.Statement = pq.statement
+		info.Destination = reflect.TypeOf(dst)
+	})
+}
+
+// SlowQuery is a query that took longer than the threshold of the SlowQueryTracer.
+type SlowQuery struct {
+	QueryInfo
+	// SQL is the compiled query sent to the database, or the name of its statement, see QueryInfo.Query.
+	SQL      string
+	Args     []interface{}
+	Duration time.Duration
+	Err      error
+}
+
+// SlowQueryTracer is a pgx.QueryTracer that logs the queries that take longer than its threshold,
+// with their orava QueryInfo. It is set as the Tracer of the pgx.ConnConfig.
+type SlowQueryTracer struct {
+	Threshold time.Duration
+	// Log is called with the slow queries, they are logged with the standard logger when it is nil.
+	Log func(ctx context.Context, query SlowQuery)
+}
+
+var _ pgx.QueryTracer = &SlowQueryTracer{}
+
+type slowQueryKey struct{}
+
+type slowQueryStart struct {
+	start time.Time
+	data  pgx.TraceQueryStartData
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *SlowQueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, slowQueryKey{}, slowQueryStart{start: time.Now(), data: data})
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *SlowQueryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(slowQueryKey{}).(slowQueryStart)
+	if !ok {
+		return
+	}
+	duration := time.Since(start.start)
+	if duration < t.Threshold {
+		return
+	}
+
+	info, _ := QueryInfoFromContext(ctx)
+	query := SlowQuery{QueryInfo: info, SQL: start.data.SQL, Args: start.data.Args, Duration: duration, Err: data.Err}
+	if t.Log != nil {
+		t.Log(ctx, query)
+		return
+	}
+	logSlowQuery(query)
+}
+
+func logSlowQuery(query SlowQuery) {
+	name := query.Name
+	if name == "" {
+		name = "query"
+	}
+	if query.NamedQuery != "" {
+		log.Printf("orava: slow %s took %v: %s, compiled: %s", name, query.Duration, query.NamedQuery, query.Query)
+		return
+	}
+	log.Printf("orava: slow %s took %v: %s", name, query.Duration, query.SQL)
+}
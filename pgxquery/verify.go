@@ -0,0 +1,126 @@
+package pgxquery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pkg/errors"
+)
+
+// VerifyError lists the mismatches between a query and the live schema found by Verify.
+type VerifyError struct {
+	Query      string
+	Mismatches []string
+}
+
+func (e *VerifyError) Error() string {
+	return "orava: query " + e.Query + " doesn't match the schema: " + strings.Join(e.Mismatches, "; ")
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// Verify prepares the query on a connection of db, so that bad SQL and schema drift are caught when the service starts.
+// The parameters that the database describes are checked against the fields of the argument structs
+// given to PrepareNamed or LoadQueries, their count, whether the structs have their fields
+// and whether the field types can be encoded as the parameter types.
+// The result columns are checked against the struct destinations given as dstExample with ValidateDestination.
+// All of the mismatches are returned in a *VerifyError.
+// Queries with {{identifiers}} are only complete with their arguments, so they are not verified.
+func (pq *PreparedQuery) Verify(ctx context.Context, db Querier, dstExample ...interface{}) error {
+	if len(pq.prep.Idents()) > 0 {
+		return nil
+	}
+	query := pq.prep.Query()
+	return withPgConn(ctx, db, func(conn *pgx.Conn) error {
+		sd, err := conn.Prepare(ctx, pq.statement, query)
+		if err != nil {
+			return errors.Wrapf(err, "orava: verify query %s", pq.describe())
+		}
+
+		mismatches := pq.verifyParams(conn.TypeMap(), sd)
+		mismatches = append(mismatches, pq.verifyColumns(sd, dstExample)...)
+		if len(mismatches) > 0 {
+			return &VerifyError{Query: pq.describe(), Mismatches: mismatches}
+		}
+		return nil
+	})
+}
+
+func (pq *PreparedQuery) verifyParams(typeMap *pgtype.Map, sd *pgconn.StatementDescription) []string {
+	params := pq.prep.NamedParams()
+	if len(sd.ParamOIDs) != len(params) {
+		return []string{fmt.Sprintf("query has %d params but the database expects %d", len(params), len(sd.ParamOIDs))}
+	}
+
+	var mismatches []string
+	for _, example := range pq.examples {
+		exampleType := reflect.TypeOf(example)
+		for exampleType != nil && exampleType.Kind() == reflect.Ptr {
+			exampleType = exampleType.Elem()
+		}
+		if exampleType == nil || exampleType.Kind() != reflect.Struct {
+			// Maps only have their value types at runtime.
+			continue
+		}
+
+		columns, fieldIndexes := pq.api.dbqueryAPI.LeafColumns(exampleType)
+		fieldTypes := make(map[string]reflect.Type, len(columns))
+		for i, column := range columns {
+			fieldTypes[column] = exampleType.FieldByIndex(fieldIndexes[i]).Type
+		}
+
+		for i, param := range params {
+			fieldType, ok := fieldTypes[param]
+			if !ok {
+				mismatches = append(mismatches, fmt.Sprintf("param $%d :%s has no field in %s", i+1, param, exampleType))
+				continue
+			}
+			pgType, ok := typeMap.TypeForOID(sd.ParamOIDs[i])
+			if !ok {
+				// Types unknown to pgx, like enums, are sent as text.
+				continue
+			}
+			value, err := pq.api.dbqueryAPI.BindValue(reflect.Zero(fieldType).Interface())
+			if err != nil {
+				continue
+			}
+			if typeMap.PlanEncode(pgType.OID, pgtype.BinaryFormatCode, value) == nil &&
+				typeMap.PlanEncode(pgType.OID, pgtype.TextFormatCode, value) == nil {
+				mismatches = append(mismatches, fmt.Sprintf("param $%d :%s is %s but the field of %s is %v",
+					i+1, param, pgType.Name, exampleType, fieldType))
+			}
+		}
+	}
+	return mismatches
+}
+
+func (pq *PreparedQuery) verifyColumns(sd *pgconn.StatementDescription, dstExamples []interface{}) []string {
+	columns := make([]string, len(sd.Fields))
+	for i, field := range sd.Fields {
+		columns[i] = field.Name
+	}
+
+	var mismatches []string
+	for _, dst := range dstExamples {
+		dstType := reflect.TypeOf(dst)
+		for dstType != nil && (dstType.Kind() == reflect.Ptr || dstType.Kind() == reflect.Slice) {
+			dstType = dstType.Elem()
+		}
+		if dstType == nil || dstType.Kind() != reflect.Struct ||
+			reflect.PtrTo(dstType).Implements(scannerType) || dstType == reflect.TypeOf(time.Time{}) {
+			// Single column destinations are scanned as they are.
+			continue
+		}
+		if err := pq.api.dbqueryAPI.ValidateDestination(columns, dst); err != nil {
+			mismatches = append(mismatches, err.Error())
+		}
+	}
+	return mismatches
+}
@@ -0,0 +1,55 @@
+package sqlquery
+
+import (
+	"io/fs"
+
+	"github.com/anton7r/orava/dbquery"
+	"github.com/pkg/errors"
+)
+
+// QueryRegistry holds the queries loaded with LoadQueries by their names.
+type QueryRegistry struct {
+	registry *dbquery.QueryRegistry
+	queries  map[string]*PreparedQuery
+}
+
+// LoadQueries loads the named queries from the .sql files matching the pattern.
+// See dbquery.API.LoadQueries for details.
+func (api *API) LoadQueries(fsys fs.FS, pattern string, examples map[string][]interface{}) (*QueryRegistry, error) {
+	registry, err := api.dbqueryAPI.LoadQueries(fsys, pattern, examples)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	queries := make(map[string]*PreparedQuery, len(registry.Names()))
+	for _, name := range registry.Names() {
+		queries[name] = &PreparedQuery{api, registry.MustGet(name)}
+	}
+
+	return &QueryRegistry{registry: registry, queries: queries}, nil
+}
+
+// Get returns the prepared query with the name
+func (r *QueryRegistry) Get(name string) (*PreparedQuery, bool) {
+	pq, found := r.queries[name]
+	return pq, found
+}
+
+// MustGet returns the prepared query with the name and panics if it does not exist
+func (r *QueryRegistry) MustGet(name string) *PreparedQuery {
+	pq, found := r.queries[name]
+	if !found {
+		panic("orava: query '" + name + "' not found")
+	}
+	return pq
+}
+
+// Def returns the parsed definition of the query with the name
+func (r *QueryRegistry) Def(name string) (dbquery.QueryDef, bool) {
+	return r.registry.Def(name)
+}
+
+// Names returns the names of all loaded queries in alphabetical order
+func (r *QueryRegistry) Names() []string {
+	return r.registry.Names()
+}
@@ -0,0 +1,32 @@
+package sqlquery_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/anton7r/orava/dbquery"
+	"github.com/anton7r/orava/sqlquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadQueries(t *testing.T) {
+	dbqueryAPI, err := dbquery.NewAPI(dbquery.WithLexer(':', dbquery.SequentialDollarDelim))
+	require.NoError(t, err)
+	api, err := sqlquery.NewAPI(dbqueryAPI)
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte("-- name: GetUser :one\r\nSELECT id FROM users WHERE id = :id;\r\n\r\n-- name: ListUsers :many\r\nSELECT id FROM users\r\n")},
+	}
+	registry, err := api.LoadQueries(fsys, "*.sql", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"GetUser", "ListUsers"}, registry.Names())
+	_, found := registry.Get("GetUser")
+	assert.True(t, found)
+	def, found := registry.Def("ListUsers")
+	require.True(t, found)
+	assert.Equal(t, "SELECT id FROM users", def.SQL)
+	assert.PanicsWithValue(t, "orava: query 'Missing' not found", func() { registry.MustGet("Missing") })
+}
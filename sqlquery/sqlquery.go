@@ -5,6 +5,7 @@ import (
 	"database/sql"
 
 	"github.com/anton7r/orava/dbquery"
+	"github.com/pkg/errors"
 )
 
 // Querier is something that sqlscan can query and get the *sql.Rows from.
@@ -31,3 +32,290 @@ func NewAPI(dbqueryAPI *dbquery.API) (*API, error) {
 	}
 	return api, nil
 }
+
+// Select is a high-level function that queries rows from Querier and calls the ScanAll function.
+// See ScanAll for details.
+func (api *API) Select(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "orava: query multiple result rows")
+	}
+	err = api.ScanAll(dst, rows)
+	return errors.WithStack(err)
+}
+
+// SelectNamed is a high-level function that queries rows from Querier and calls the ScanAll function.
+// See ScanAll for details.
+func (api *API) SelectNamed(ctx context.Context, db Querier, dst interface{}, query string, arg interface{}) error {
+	compiledQuery, args, err := api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return err
+	}
+
+	return api.Select(ctx, db, dst, compiledQuery, args...)
+}
+
+// Get is a high-level function that queries rows from Querier and calls the ScanOne function.
+// See ScanOne for details.
+func (api *API) Get(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "orava: query one result row")
+	}
+	err = api.ScanOne(dst, rows)
+	return errors.WithStack(err)
+}
+
+// GetNamed is a high-level function that queries rows from Querier and calls the ScanOne function.
+// See ScanOne for details.
+func (api *API) GetNamed(ctx context.Context, db Querier, dst interface{}, query string, arg interface{}) error {
+	compiledQuery, args, err := api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return err
+	}
+
+	return api.Get(ctx, db, dst, compiledQuery, args...)
+}
+
+// GetFirst is a high-level function that queries rows from Querier and calls the ScanFirst function.
+// See ScanFirst for details.
+func (api *API) GetFirst(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "orava: query first result row")
+	}
+	err = api.ScanFirst(dst, rows)
+	return errors.WithStack(err)
+}
+
+// GetFirstNamed is a high-level function that queries rows from Querier and calls the ScanFirst function.
+// See ScanFirst for details.
+func (api *API) GetFirstNamed(ctx context.Context, db Querier, dst interface{}, query string, arg interface{}) error {
+	compiledQuery, args, err := api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return err
+	}
+
+	return api.GetFirst(ctx, db, dst, compiledQuery, args...)
+}
+
+// GetOptional is a high-level function that queries rows from Querier and calls the ScanOptional function.
+// See ScanOptional for details.
+func (api *API) GetOptional(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) (bool, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "orava: query optional result row")
+	}
+	found, err := api.ScanOptional(dst, rows)
+	return found, errors.WithStack(err)
+}
+
+// GetOptionalNamed is a high-level function that queries rows from Querier and calls the ScanOptional function.
+// See ScanOptional for details.
+func (api *API) GetOptionalNamed(ctx context.Context, db Querier, dst interface{}, query string, arg interface{}) (bool, error) {
+	compiledQuery, args, err := api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return false, err
+	}
+
+	return api.GetOptional(ctx, db, dst, compiledQuery, args...)
+}
+
+// Exec is a high-level function that sends an executable action to the database
+func (api *API) Exec(ctx context.Context, db Querier, query string, args ...interface{}) (sql.Result, error) {
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "orava: exec")
+	}
+
+	return result, nil
+}
+
+// ExecNamed is a high-level function that sends an executable action to the database with named parameters
+func (api *API) ExecNamed(ctx context.Context, db Querier, query string, arg interface{}) (sql.Result, error) {
+	compiledQuery, args, err := api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.Exec(ctx, db, compiledQuery, args...)
+}
+
+// QueryNamed is a high-level function that is used to retrieve *sql.Rows from the database with named parameters
+func (api *API) QueryNamed(ctx context.Context, db Querier, query string, arg interface{}) (*sql.Rows, error) {
+	compiledQuery, args, err := api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.QueryContext(ctx, compiledQuery, args...)
+}
+
+type PreparedQuery struct {
+	api  *API
+	prep *dbquery.PreparedQuery
+}
+
+func (api *API) PrepareNamed(query string, assertableStruct ...interface{}) (*PreparedQuery, error) {
+	dbPrep, err := api.dbqueryAPI.PrepareNamed(query, assertableStruct...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &PreparedQuery{api, dbPrep}, nil
+}
+
+// SelectNamed is a high-level function that queries rows from Querier and calls the ScanAll function.
+// See ScanAll for details.
+func (pq *PreparedQuery) SelectNamed(ctx context.Context, db Querier, dst interface{}, arg interface{}) error {
+	query, args, err := pq.prep.GetQuery(arg)
+	if err != nil {
+		return err
+	}
+
+	return pq.api.Select(ctx, db, dst, query, args...)
+}
+
+// GetNamed is a high-level function that queries rows from Querier and calls the ScanOne function.
+// See ScanOne for details.
+func (pq *PreparedQuery) GetNamed(ctx context.Context, db Querier, dst interface{}, arg interface{}) error {
+	query, args, err := pq.prep.GetQuery(arg)
+	if err != nil {
+		return err
+	}
+
+	return pq.api.Get(ctx, db, dst, query, args...)
+}
+
+// ExecNamed is a high-level function that sends an executable action to the database with named parameters
+func (pq *PreparedQuery) ExecNamed(ctx context.Context, db Querier, arg interface{}) (sql.Result, error) {
+	query, args, err := pq.prep.GetQuery(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return pq.api.Exec(ctx, db, query, args...)
+}
+
+// QueryNamed is a high-level function that is used to retrieve *sql.Rows from the database with named parameters
+func (pq *PreparedQuery) QueryNamed(ctx context.Context, db Querier, arg interface{}) (*sql.Rows, error) {
+	query, args, err := pq.prep.GetQuery(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.QueryContext(ctx, query, args...)
+}
+
+// NotFound is a helper function to check if an error
+// is `sql.ErrNoRows`.
+func NotFound(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+// ScanAll is a wrapper around the dbquery.ScanAll function.
+// See dbquery.ScanAll for details.
+func (api *API) ScanAll(dst interface{}, rows *sql.Rows) error {
+	err := api.dbqueryAPI.ScanAll(dst, rows)
+	return errors.WithStack(err)
+}
+
+// ScanOne is a wrapper around the dbquery.ScanOne function.
+// See dbquery.ScanOne for details. If no rows are found it
+// returns an sql.ErrNoRows error.
+func (api *API) ScanOne(dst interface{}, rows *sql.Rows) error {
+	err := api.dbqueryAPI.ScanOne(dst, rows)
+	if dbquery.NotFound(err) {
+		return errors.WithStack(sql.ErrNoRows)
+	}
+	return errors.WithStack(err)
+}
+
+// ScanFirst is a wrapper around the dbquery.ScanFirst function.
+// See dbquery.ScanFirst for details. If no rows are found it
+// returns an sql.ErrNoRows error.
+func (api *API) ScanFirst(dst interface{}, rows *sql.Rows) error {
+	err := api.dbqueryAPI.ScanFirst(dst, rows)
+	if dbquery.NotFound(err) {
+		return errors.WithStack(sql.ErrNoRows)
+	}
+	return errors.WithStack(err)
+}
+
+// ScanOptional is a wrapper around the dbquery.ScanOptional function.
+// See dbquery.ScanOptional for details.
+func (api *API) ScanOptional(dst interface{}, rows *sql.Rows) (bool, error) {
+	found, err := api.dbqueryAPI.ScanOptional(dst, rows)
+	return found, errors.WithStack(err)
+}
+
+// ScanRow is a wrapper around the dbquery.ScanRow function.
+// See dbquery.ScanRow for details.
+func (api *API) ScanRow(dst interface{}, rows *sql.Rows) error {
+	err := api.dbqueryAPI.ScanRow(dst, rows)
+	return errors.WithStack(err)
+}
+
+// SelectIndexed is a high-level function that queries rows from Querier and calls the ScanIndexed function.
+// See ScanIndexed for details.
+func (api *API) SelectIndexed(ctx context.Context, db Querier, dst interface{}, keyColumn string, query string, args ...interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "orava: query indexed rows")
+	}
+	err = api.ScanIndexed(dst, rows, keyColumn)
+	return errors.WithStack(err)
+}
+
+// SelectIndexedNamed is a high-level function that queries rows from Querier and calls the ScanIndexed function.
+// See ScanIndexed for details.
+func (api *API) SelectIndexedNamed(ctx context.Context, db Querier, dst interface{}, keyColumn string, query string, arg interface{}) error {
+	compiledQuery, args, err := api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return err
+	}
+
+	return api.SelectIndexed(ctx, db, dst, keyColumn, compiledQuery, args...)
+}
+
+// ScanIndexed is a wrapper around the dbquery.ScanIndexed function.
+// See dbquery.ScanIndexed for details.
+func (api *API) ScanIndexed(dst interface{}, rows *sql.Rows, keyColumn string) error {
+	err := api.dbqueryAPI.ScanIndexed(dst, rows, keyColumn)
+	return errors.WithStack(err)
+}
+
+// SelectColumn is a high-level function that queries rows from Querier and calls the ScanColumn function.
+// See ScanColumn for details.
+func SelectColumn[T any](ctx context.Context, api *API, db Querier, query string, args ...interface{}) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "orava: query column rows")
+	}
+	values, err := ScanColumn[T](api, rows)
+	return values, errors.WithStack(err)
+}
+
+// SelectColumnNamed is a high-level function that queries rows from Querier and calls the ScanColumn function.
+// See ScanColumn for details.
+func SelectColumnNamed[T any](ctx context.Context, api *API, db Querier, query string, arg interface{}) ([]T, error) {
+	compiledQuery, args, err := api.dbqueryAPI.NamedQueryParams(query, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return SelectColumn[T](ctx, api, db, compiledQuery, args...)
+}
+
+// ScanColumn is a wrapper around the dbquery.ScanColumn function.
+// See dbquery.ScanColumn for details.
+func ScanColumn[T any](api *API, rows *sql.Rows) ([]T, error) {
+	values, err := dbquery.ScanColumn[T](api.dbqueryAPI, rows)
+	return values, errors.WithStack(err)
+}
+
+// NewRowScanner returns a new RowScanner instance wrapping the *sql.Rows.
+// See dbquery.RowScanner for details.
+func (api *API) NewRowScanner(rows *sql.Rows) *dbquery.RowScanner {
+	return api.dbqueryAPI.NewRowScanner(rows)
+}
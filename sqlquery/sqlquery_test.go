@@ -0,0 +1,244 @@
+package sqlquery_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/anton7r/orava/dbquery"
+	"github.com/anton7r/orava/sqlquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ctx = context.Background()
+
+type testModel struct {
+	Foo string
+	Bar string
+}
+
+const (
+	multipleRowsQuery = "SELECT foo, bar FROM models"
+	noRowsQuery       = "SELECT foo, bar FROM models WHERE false"
+	namedQuery        = "SELECT foo, bar FROM models WHERE foo = :foo"
+	compiledQuery     = "SELECT foo, bar FROM models WHERE foo = $1"
+	execQuery         = "DELETE FROM models WHERE foo = :foo"
+	columnQuery       = "SELECT foo FROM models WHERE foo = :foo"
+)
+
+// fakeDriver answers the queries with the results registered for their SQL
+// and records the arguments of the last statement.
+type fakeDriver struct {
+	mu       sync.Mutex
+	results  map[string]fakeResult
+	lastArgs []driver.Value
+}
+
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+var testDriver = &fakeDriver{results: map[string]fakeResult{
+	multipleRowsQuery: {
+		columns: []string{"foo", "bar"},
+		rows:    [][]driver.Value{{"foo val", "bar val"}, {"foo val 2", "bar val 2"}, {"foo val 3", "bar val 3"}},
+	},
+	noRowsQuery: {columns: []string{"foo", "bar"}},
+	compiledQuery: {
+		columns: []string{"foo", "bar"},
+		rows:    [][]driver.Value{{"foo val 2", "bar val 2"}},
+	},
+	"SELECT foo FROM models WHERE foo = $1": {
+		columns: []string{"foo"},
+		rows:    [][]driver.Value{{"foo val 2"}},
+	},
+}}
+
+func init() {
+	sql.Register("orava-fake", testDriver)
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{driver: d}, nil
+}
+
+func (d *fakeDriver) LastArgs() []driver.Value {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lastArgs
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{driver: c.driver, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+type fakeStmt struct {
+	driver *fakeDriver
+	query  string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.mu.Lock()
+	defer s.driver.mu.Unlock()
+	s.driver.lastArgs = args
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.driver.mu.Lock()
+	defer s.driver.mu.Unlock()
+	s.driver.lastArgs = args
+	result := s.driver.results[s.query]
+	return &fakeRows{result: result}, nil
+}
+
+type fakeRows struct {
+	result fakeResult
+	pos    int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.result.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+func getAPI(t *testing.T) (*sqlquery.API, *sql.DB) {
+	dbqueryAPI, err := dbquery.NewAPI(dbquery.WithLexer(':', dbquery.SequentialDollarDelim))
+	require.NoError(t, err)
+	api, err := sqlquery.NewAPI(dbqueryAPI)
+	require.NoError(t, err)
+	db, err := sql.Open("orava-fake", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return api, db
+}
+
+func TestSelect(t *testing.T) {
+	api, db := getAPI(t)
+
+	var got []*testModel
+	err := api.Select(ctx, db, &got, multipleRowsQuery)
+	require.NoError(t, err)
+
+	expected := []*testModel{
+		{Foo: "foo val", Bar: "bar val"},
+		{Foo: "foo val 2", Bar: "bar val 2"},
+		{Foo: "foo val 3", Bar: "bar val 3"},
+	}
+	assert.Equal(t, expected, got)
+}
+
+func TestSelectNamed(t *testing.T) {
+	api, db := getAPI(t)
+
+	var got []testModel
+	err := api.SelectNamed(ctx, db, &got, namedQuery, &testModel{Foo: "foo val 2"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []testModel{{Foo: "foo val 2", Bar: "bar val 2"}}, got)
+}
+
+func TestGet_noRows_returnsNotFoundErr(t *testing.T) {
+	api, db := getAPI(t)
+
+	var got testModel
+	err := api.Get(ctx, db, &got, noRowsQuery)
+	assert.True(t, sqlquery.NotFound(err))
+}
+
+func TestGetFirst(t *testing.T) {
+	api, db := getAPI(t)
+
+	var got testModel
+	err := api.GetFirst(ctx, db, &got, multipleRowsQuery)
+	require.NoError(t, err)
+	assert.Equal(t, testModel{Foo: "foo val", Bar: "bar val"}, got)
+
+	err = api.GetFirst(ctx, db, &got, noRowsQuery)
+	assert.True(t, sqlquery.NotFound(err))
+}
+
+func TestGetOptional(t *testing.T) {
+	api, db := getAPI(t)
+
+	var got testModel
+	found, err := api.GetOptionalNamed(ctx, db, &got, namedQuery, &testModel{Foo: "foo val 2"})
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, testModel{Foo: "foo val 2", Bar: "bar val 2"}, got)
+
+	found, err = api.GetOptional(ctx, db, &got, noRowsQuery)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestExecNamed(t *testing.T) {
+	api, db := getAPI(t)
+
+	result, err := api.ExecNamed(ctx, db, execQuery, &testModel{Foo: "foo val"})
+	require.NoError(t, err)
+	affected, err := result.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	assert.Equal(t, []driver.Value{"foo val"}, testDriver.LastArgs())
+}
+
+func TestSelectIndexed(t *testing.T) {
+	api, db := getAPI(t)
+
+	var got map[string]testModel
+	err := api.SelectIndexed(ctx, db, &got, "foo", multipleRowsQuery)
+	require.NoError(t, err)
+
+	expected := map[string]testModel{
+		"foo val":   {Foo: "foo val", Bar: "bar val"},
+		"foo val 2": {Foo: "foo val 2", Bar: "bar val 2"},
+		"foo val 3": {Foo: "foo val 3", Bar: "bar val 3"},
+	}
+	assert.Equal(t, expected, got)
+}
+
+func TestSelectColumnNamed(t *testing.T) {
+	api, db := getAPI(t)
+
+	got, err := sqlquery.SelectColumnNamed[string](ctx, api, db, columnQuery, &testModel{Foo: "foo val 2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo val 2"}, got)
+}